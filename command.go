@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/database"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
+)
+
+// importCommand imports a CSV or XLSX file of farms and ponds
+//
+//	go run . import [-dry-run] [-actor name] [-organization name] file
+func importCommand(args []string, importHandler *handler.ImportHandler, organizationRepository repository.OrganizationRepository) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without committing it")
+	actor := flags.String("actor", "cli", "actor recorded in the audit trail")
+	organizationName := flags.String("organization", defaultOrganizationName, "organization owning the farms and ponds")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: import [-dry-run] [-actor name] [-organization name] file")
+	}
+	organization, err := findOrganization(organizationRepository, *organizationName)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := handler.ParseImportRows(file, file.Name())
+	if err != nil {
+		return err
+	}
+	results, valid, err := importHandler.Import(rows, *dryRun, *actor, organization.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		status := "info"
+		if result.Status == model.BulkError {
+			status = "error"
+		}
+		line := fmt.Sprintf("Row %d: farm %q", result.Row, result.Farm)
+		if result.Pond != "" {
+			line += fmt.Sprintf(", pond %q", result.Pond)
+		}
+		line += " " + string(result.Status)
+		if result.Message != "" {
+			line += ": " + result.Message
+		}
+		utility.PrintConsole(line, status)
+	}
+
+	if !valid {
+		return errors.New("import rejected, nothing was committed")
+	}
+	if *dryRun {
+		utility.PrintConsole("Import validated, nothing was committed", "info")
+	} else {
+		utility.PrintConsole("Farm and pond imported successfully", "info")
+	}
+	return nil
+}
+
+// migrateCommand applies, rolls back or lists the embedded migrations
+//
+//	go run . migrate up
+//	go run . migrate down [-steps n]
+//	go run . migrate status
+func migrateCommand(args []string, gormDB *gorm.DB) error {
+	usage := errors.New("usage: migrate up | down [-steps n] | status")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "up":
+		migrations, err := database.MigrateUp(gormDB)
+		for _, migration := range migrations {
+			utility.PrintConsole(fmt.Sprintf("Applied %04d_%s", migration.Version, migration.Name), "info")
+		}
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			utility.PrintConsole("Database schema is up to date", "info")
+		}
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to roll back")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *steps <= 0 {
+			return usage
+		}
+
+		migrations, err := database.MigrateDown(gormDB, *steps)
+		for _, migration := range migrations {
+			utility.PrintConsole(fmt.Sprintf("Rolled back %04d_%s", migration.Version, migration.Name), "info")
+		}
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			utility.PrintConsole("No migration to roll back", "info")
+		}
+	case "status":
+		statuses, err := database.MigrationStatuses(gormDB)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.AppliedAt == nil {
+				utility.PrintConsole(fmt.Sprintf("%04d_%s pending", status.Version, status.Name), "warning")
+			} else {
+				utility.PrintConsole(fmt.Sprintf("%04d_%s applied at %s", status.Version, status.Name, status.AppliedAt.Format(time.RFC3339)), "info")
+			}
+		}
+	default:
+		return usage
+	}
+	return nil
+}
+
+// apiKeyCommand issues, lists or revokes API keys. A key is only shown when
+// it is created, as only its hash is stored.
+//
+//	go run . apikey create -name name -scopes farm:read,pond:write [-role role] [-organization name]
+//	go run . apikey list
+//	go run . apikey revoke id
+func apiKeyCommand(args []string, apiKeyRepository repository.ApiKeyRepository, organizationRepository repository.OrganizationRepository) error {
+	usage := errors.New("usage: apikey create -name name -scopes scopes [-role role] [-organization name] | list | revoke id")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		name := flags.String("name", "", "name of the key, recorded as the audit actor")
+		scopes := flags.String("scopes", "", "comma separated group:read or group:write scopes")
+		role := flags.String("role", model.RoleViewer, "viewer, operator or admin")
+		organizationName := flags.String("organization", defaultOrganizationName, "organization whose farms and ponds the key reaches")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *name == "" {
+			return usage
+		}
+		parsedScopes, err := model.ParseScopes(*scopes)
+		if err != nil {
+			return err
+		}
+		if !model.IsValidRole(*role) {
+			return fmt.Errorf("invalid role %q, expected viewer, operator or admin", *role)
+		}
+		organization, err := findOrganization(organizationRepository, *organizationName)
+		if err != nil {
+			return err
+		}
+
+		key, err := utility.GenerateApiKey()
+		if err != nil {
+			return err
+		}
+		apiKey := model.ApiKey{
+			Name:           *name,
+			Prefix:         key[:utility.ApiKeyPrefixLength],
+			KeyHash:        utility.HashApiKey(key),
+			Scopes:         parsedScopes,
+			Role:           *role,
+			OrganizationID: organization.ID,
+			CreatedAt:      time.Now().UTC(),
+		}
+		if err := apiKeyRepository.Create(&apiKey); err != nil {
+			return err
+		}
+		utility.PrintConsole(fmt.Sprintf("Created API key %d %q with scopes %s and role %s in organization %q, farm member subject %s", apiKey.ID, apiKey.Name, apiKey.Scopes, apiKey.Role, organization.Name, model.ApiKeyMember(apiKey.ID)), "info")
+		utility.PrintConsole("Store the key now, it cannot be shown again: "+key, "warning")
+	case "list":
+		apiKeys, err := apiKeyRepository.List()
+		if err != nil {
+			return err
+		}
+		for _, apiKey := range apiKeys {
+			line := fmt.Sprintf("%d %q %s... %s %s organization %d created at %s", apiKey.ID, apiKey.Name, apiKey.Prefix, apiKey.Scopes, apiKey.Role, apiKey.OrganizationID, apiKey.CreatedAt.Format(time.RFC3339))
+			if apiKey.RevokedAt != nil {
+				utility.PrintConsole(line+", revoked at "+apiKey.RevokedAt.Format(time.RFC3339), "warning")
+			} else {
+				utility.PrintConsole(line, "info")
+			}
+		}
+	case "revoke":
+		if len(args) != 2 {
+			return usage
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return usage
+		}
+		if err := apiKeyRepository.Revoke(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("no active API key with id %d", id)
+			}
+			return err
+		}
+		utility.PrintConsole(fmt.Sprintf("Revoked API key %d", id), "info")
+	default:
+		return usage
+	}
+	return nil
+}
+
+// defaultOrganizationName is the organization created by the migrations for
+// the farms, ponds and API keys predating organizations
+const defaultOrganizationName = "default"
+
+// organizationCommand creates or lists the organizations owning farms and
+// ponds
+//
+//	go run . organization create name
+//	go run . organization list
+func organizationCommand(args []string, organizationRepository repository.OrganizationRepository) error {
+	usage := errors.New("usage: organization create name | list")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) != 2 || args[1] == "" {
+			return usage
+		}
+		if existing, _ := organizationRepository.GetByName(args[1]); existing != nil {
+			return fmt.Errorf("organization %q already exists", args[1])
+		}
+		organization := model.Organization{
+			Name:      args[1],
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := organizationRepository.Create(&organization); err != nil {
+			return err
+		}
+		utility.PrintConsole(fmt.Sprintf("Created organization %d %q", organization.ID, organization.Name), "info")
+	case "list":
+		organizations, err := organizationRepository.List()
+		if err != nil {
+			return err
+		}
+		for _, organization := range organizations {
+			utility.PrintConsole(fmt.Sprintf("%d %q created at %s", organization.ID, organization.Name, organization.CreatedAt.Format(time.RFC3339)), "info")
+		}
+	default:
+		return usage
+	}
+	return nil
+}
+
+// findOrganization resolves the -organization flag of a command
+func findOrganization(organizationRepository repository.OrganizationRepository, name string) (*model.Organization, error) {
+	organization, err := organizationRepository.GetByName(name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("no organization named %q", name)
+	}
+	return organization, err
+}
@@ -4,7 +4,6 @@ set -e
 host="$1"
 port="$2"
 shift 2
-cmd="$@"
 
 until mysqladmin ping -h "$host" -P "$port" --silent; do
     echo "Waiting for MySQL to be available at $host:$port..."
@@ -12,4 +11,4 @@ until mysqladmin ping -h "$host" -P "$port" --silent; do
 done
 
 echo "MySQL is available, executing command"
-exec $cmd
+exec "$@"
@@ -3,25 +3,88 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 
+	"github.com/glebarez/sqlite"
 	gormMySQL "gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
 )
 
+// Drivers selectable by the database.driver configuration, named after the
+// gorm dialect
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sqliteMemory is the SQLite path of a database held in memory, the default
+const sqliteMemory = ":memory:"
+
+// sqliteParams enforces foreign keys, which SQLite leaves off by default
+const sqliteParams = "?_pragma=foreign_keys(1)&_time_format=sqlite"
+
 func Open(conf utility.Configuration) (db *sql.DB, gormDB *gorm.DB, err error) {
 	defer utility.RecoverError()
 
-	strConnectionString := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", conf.Database.Username, conf.Database.Password, conf.Database.Hostname, conf.Database.Port, conf.Database.DatabaseName)
-	db, err = sql.Open("mysql", strConnectionString)
-	if err != nil {
-		return
-	}
+	switch conf.Database.Driver {
+	case "", DriverMySQL:
+		strConnectionString := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true", conf.Database.Username, conf.Database.Password, conf.Database.Hostname, conf.Database.Port, conf.Database.DatabaseName)
+		db, err = sql.Open("mysql", strConnectionString)
+		if err != nil {
+			return
+		}
+
+		gormDB, err = gorm.Open(gormMySQL.New(gormMySQL.Config{
+			Conn: db,
+		}), &gorm.Config{})
+	case DriverPostgres:
+		sslMode := conf.Database.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(conf.Database.Username, conf.Database.Password),
+			Host:     fmt.Sprintf("%s:%d", conf.Database.Hostname, conf.Database.Port),
+			Path:     conf.Database.DatabaseName,
+			RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+		}
+		gormDB, err = gorm.Open(postgres.Open(dsn.String()), &gorm.Config{})
+		if err != nil {
+			return
+		}
+		db, err = gormDB.DB()
+	case DriverSQLite:
+		path := conf.Database.Path
+		if path == "" {
+			path = sqliteMemory
+		}
+		gormDB, err = gorm.Open(sqlite.Open(path+sqliteParams), &gorm.Config{})
+		if err != nil {
+			return
+		}
 
-	gormDB, err = gorm.Open(gormMySQL.New(gormMySQL.Config{
-		Conn: db,
-	}), &gorm.Config{})
+		// A single connection serializes writers, and keeps every query on
+		// the same in-memory database
+		db, err = gormDB.DB()
+		if err != nil {
+			return
+		}
+		db.SetMaxOpenConns(1)
+	default:
+		err = fmt.Errorf("unsupported database driver %q", conf.Database.Driver)
+	}
 
 	return
-}
\ No newline at end of file
+}
+
+// InMemory reports whether conf opens an in-memory SQLite database, which
+// lives only as long as the process and so cannot be migrated beforehand by
+// `migrate up`
+func InMemory(conf utility.Configuration) bool {
+	return conf.Database.Driver == DriverSQLite && (conf.Database.Path == "" || conf.Database.Path == sqliteMemory)
+}
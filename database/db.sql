@@ -1,18 +0,0 @@
-USE delos_db;
-
-CREATE TABLE farms (
-    id INT PRIMARY KEY AUTO_INCREMENT,
-    name VARCHAR(255) UNIQUE NOT NULL
-);
-
-CREATE TABLE ponds (
-    id INT PRIMARY KEY AUTO_INCREMENT,
-    name VARCHAR(255) UNIQUE NOT NULL,
-    farm_id INT NOT NULL
-);
-
-CREATE TABLE logs (
-    id INT PRIMARY KEY AUTO_INCREMENT,
-    endpoint VARCHAR(255) NOT NULL,
-    user_agent VARCHAR(255) NOT NULL
-);
\ No newline at end of file
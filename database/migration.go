@@ -0,0 +1,295 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrMigrationsPending is returned by CheckMigrations when the database
+// schema is behind the migrations embedded in the binary
+var ErrMigrationsPending = errors.New("database schema is behind, run `migrate up`")
+
+// timestampTypes is the column type of applied_at for each dialect
+var timestampTypes = map[string]string{
+	DriverMySQL:    "DATETIME(6)",
+	DriverPostgres: "TIMESTAMP(6)",
+	DriverSQLite:   "DATETIME",
+}
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at %s NOT NULL
+)`
+
+// Migration is a pair of NNNN_name.up.sql and NNNN_name.down.sql files. A
+// NNNN_name.<dialect>.up.sql or .down.sql file replaces the shared one on that
+// dialect, for statements that are not portable.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration along with when it was applied, nil while
+// pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrations lists the embedded migrations of a dialect ordered by version
+func Migrations(dialect string) ([]Migration, error) {
+	if _, ok := timestampTypes[dialect]; !ok {
+		return nil, fmt.Errorf("unsupported migration dialect %q", dialect)
+	}
+
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	overridden := make(map[string]bool)
+	for _, file := range files {
+		base := path.Base(file)
+		prefix, rest, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", base)
+		}
+
+		content, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var direction string
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(rest, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("invalid migration file name %q", base)
+		}
+		name := strings.TrimSuffix(rest, "."+direction+".sql")
+
+		// Dialect specific file
+		name, fileDialect, specific := strings.Cut(name, ".")
+		key := fmt.Sprintf("%d.%s", version, direction)
+		if specific && fileDialect != dialect || !specific && overridden[key] {
+			continue
+		}
+		overridden[key] = specific
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version}
+			byVersion[version] = migration
+		}
+		migration.Name = name
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d is missing its up or down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// MigrationStatuses lists every embedded migration and whether it is applied
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Exec(fmt.Sprintf(createSchemaMigrations, timestampTypes[db.Dialector.Name()])).Error; err != nil {
+		return nil, err
+	}
+
+	var applied []schemaMigration
+	if err := db.Table("schema_migrations").Order("version").Scan(&applied).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[int]time.Time, len(applied))
+	for _, migration := range applied {
+		appliedAt[migration.Version] = migration.AppliedAt
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		statuses[i].Migration = migration
+		if at, ok := appliedAt[migration.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// MigrateUp applies every pending migration in order, returning the applied
+// ones
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]Migration, 0)
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+		record := schemaMigration{Version: status.Version, Name: status.Name, AppliedAt: time.Now().UTC()}
+		err := applyMigration(db, status.Migration.Up, func(tx *gorm.DB) error {
+			return tx.Table("schema_migrations").Create(&record).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s up: %w", status.Version, status.Name, err)
+		}
+		applied = append(applied, status.Migration)
+	}
+	return applied, nil
+}
+
+// MigrateDown rolls back the last steps applied migrations in reverse order,
+// returning the rolled back ones
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	rolledBack := make([]Migration, 0, steps)
+	for i := len(statuses) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		status := statuses[i]
+		if status.AppliedAt == nil {
+			continue
+		}
+		err := applyMigration(db, status.Migration.Down, func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", status.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("migration %04d_%s down: %w", status.Version, status.Name, err)
+		}
+		rolledBack = append(rolledBack, status.Migration)
+	}
+	return rolledBack, nil
+}
+
+// CheckMigrations returns ErrMigrationsPending unless every embedded
+// migration is applied
+func CheckMigrations(db *gorm.DB) error {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			return ErrMigrationsPending
+		}
+	}
+	return nil
+}
+
+// applyMigration runs the statements of a migration file then record, which
+// updates schema_migrations, in one transaction on Postgres and SQLite, so a
+// failing migration leaves neither its changes nor its version behind. MySQL
+// commits each DDL statement implicitly, so a migration failing partway
+// through stays half applied there and must be completed or undone by hand
+// before running it again.
+func applyMigration(db *gorm.DB, content string, record func(tx *gorm.DB) error) error {
+	switch db.Dialector.Name() {
+	case DriverMySQL:
+		if err := runMigration(db, content); err != nil {
+			return err
+		}
+		return record(db)
+	case DriverSQLite:
+		// Foreign keys cannot be switched off within a transaction, so they
+		// are off around it while migrations rebuild tables, and checked
+		// before committing
+		if err := db.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+			return err
+		}
+		defer db.Exec("PRAGMA foreign_keys = ON")
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := runMigration(tx, content); err != nil {
+			return err
+		}
+		if tx.Dialector.Name() == DriverSQLite {
+			if err := checkForeignKeys(tx); err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	})
+}
+
+// checkForeignKeys returns an error naming the first table holding a row
+// whose SQLite foreign key has no parent row
+func checkForeignKeys(tx *gorm.DB) error {
+	rows, err := tx.Raw("PRAGMA foreign_key_check").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table, parent string
+		var rowID, foreignKeyID sql.NullInt64
+		if err := rows.Scan(&table, &rowID, &parent, &foreignKeyID); err != nil {
+			return err
+		}
+		return fmt.Errorf("row %d of %s references a missing row of %s", rowID.Int64, table, parent)
+	}
+	return rows.Err()
+}
+
+// runMigration executes the statements of a migration file one at a time, as
+// the MySQL driver refuses multiple statements per query. Statements are
+// split on semicolons, so migrations must not use them in string literals.
+func runMigration(db *gorm.DB, content string) error {
+	for _, statement := range strings.Split(content, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
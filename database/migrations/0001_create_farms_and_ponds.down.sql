@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ponds;
+
+DROP TABLE IF EXISTS farms;
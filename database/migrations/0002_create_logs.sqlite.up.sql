@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    endpoint VARCHAR(255) NOT NULL,
+    user_agent VARCHAR(255) NOT NULL
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS logs (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    endpoint VARCHAR(255) NOT NULL,
+    user_agent VARCHAR(255) NOT NULL
+);
@@ -0,0 +1,10 @@
+ALTER TABLE logs
+    DROP INDEX idx_logs_endpoint,
+    DROP INDEX idx_logs_created_at,
+    DROP COLUMN client_ip,
+    DROP COLUMN response_size,
+    DROP COLUMN latency_us,
+    DROP COLUMN status_code,
+    DROP COLUMN route,
+    DROP COLUMN method,
+    DROP COLUMN created_at;
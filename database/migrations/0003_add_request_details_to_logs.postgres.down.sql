@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_logs_endpoint;
+
+DROP INDEX IF EXISTS idx_logs_created_at;
+
+ALTER TABLE logs
+    DROP COLUMN client_ip,
+    DROP COLUMN response_size,
+    DROP COLUMN latency_us,
+    DROP COLUMN status_code,
+    DROP COLUMN route,
+    DROP COLUMN method,
+    DROP COLUMN created_at;
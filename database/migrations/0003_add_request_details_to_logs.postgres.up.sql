@@ -0,0 +1,12 @@
+ALTER TABLE logs
+    ADD COLUMN created_at TIMESTAMP(6) NOT NULL DEFAULT '1970-01-01 00:00:00',
+    ADD COLUMN method VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN route VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN status_code INT NOT NULL DEFAULT 0,
+    ADD COLUMN latency_us BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN response_size INT NOT NULL DEFAULT 0,
+    ADD COLUMN client_ip VARCHAR(45) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_logs_created_at ON logs (created_at);
+
+CREATE INDEX IF NOT EXISTS idx_logs_endpoint ON logs (endpoint);
@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_logs_endpoint;
+
+DROP INDEX IF EXISTS idx_logs_created_at;
+
+ALTER TABLE logs DROP COLUMN client_ip;
+
+ALTER TABLE logs DROP COLUMN response_size;
+
+ALTER TABLE logs DROP COLUMN latency_us;
+
+ALTER TABLE logs DROP COLUMN status_code;
+
+ALTER TABLE logs DROP COLUMN route;
+
+ALTER TABLE logs DROP COLUMN method;
+
+ALTER TABLE logs DROP COLUMN created_at;
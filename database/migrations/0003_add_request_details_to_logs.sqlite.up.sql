@@ -0,0 +1,17 @@
+ALTER TABLE logs ADD COLUMN created_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+
+ALTER TABLE logs ADD COLUMN method VARCHAR(16) NOT NULL DEFAULT '';
+
+ALTER TABLE logs ADD COLUMN route VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE logs ADD COLUMN status_code INT NOT NULL DEFAULT 0;
+
+ALTER TABLE logs ADD COLUMN latency_us BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE logs ADD COLUMN response_size INT NOT NULL DEFAULT 0;
+
+ALTER TABLE logs ADD COLUMN client_ip VARCHAR(45) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_logs_created_at ON logs (created_at);
+
+CREATE INDEX IF NOT EXISTS idx_logs_endpoint ON logs (endpoint);
@@ -0,0 +1,3 @@
+ALTER TABLE ponds DROP FOREIGN KEY fk_ponds_farm;
+
+ALTER TABLE ponds DROP INDEX fk_ponds_farm;
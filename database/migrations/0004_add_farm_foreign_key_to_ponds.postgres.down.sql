@@ -0,0 +1 @@
+ALTER TABLE ponds DROP CONSTRAINT fk_ponds_farm;
@@ -0,0 +1,11 @@
+CREATE TABLE ponds_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    farm_id INT NOT NULL
+);
+
+INSERT INTO ponds_old (id, name, farm_id) SELECT id, name, farm_id FROM ponds;
+
+DROP TABLE ponds;
+
+ALTER TABLE ponds_old RENAME TO ponds;
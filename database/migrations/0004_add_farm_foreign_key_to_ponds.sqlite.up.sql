@@ -0,0 +1,14 @@
+DELETE FROM ponds WHERE NOT EXISTS (SELECT 1 FROM farms WHERE farms.id = ponds.farm_id);
+
+CREATE TABLE ponds_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    farm_id INT NOT NULL,
+    FOREIGN KEY (farm_id) REFERENCES farms(id) ON DELETE RESTRICT
+);
+
+INSERT INTO ponds_new (id, name, farm_id) SELECT id, name, farm_id FROM ponds;
+
+DROP TABLE ponds;
+
+ALTER TABLE ponds_new RENAME TO ponds;
@@ -0,0 +1,3 @@
+DELETE FROM ponds WHERE NOT EXISTS (SELECT 1 FROM farms WHERE farms.id = ponds.farm_id);
+
+ALTER TABLE ponds ADD CONSTRAINT fk_ponds_farm FOREIGN KEY (farm_id) REFERENCES farms(id) ON DELETE RESTRICT;
@@ -0,0 +1,3 @@
+ALTER TABLE ponds DROP COLUMN deleted_at;
+
+ALTER TABLE farms DROP COLUMN deleted_at;
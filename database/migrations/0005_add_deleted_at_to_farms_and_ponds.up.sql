@@ -0,0 +1,3 @@
+ALTER TABLE farms ADD COLUMN deleted_at DATETIME(6) NULL;
+
+ALTER TABLE ponds ADD COLUMN deleted_at DATETIME(6) NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS audits (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP(6) NOT NULL,
+    entity VARCHAR(32) NOT NULL,
+    entity_id INT NOT NULL,
+    operation VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    before_data JSON NULL,
+    after_data JSON NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audits_entity ON audits (entity, entity_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS audits (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    created_at DATETIME(6) NOT NULL,
+    entity VARCHAR(32) NOT NULL,
+    entity_id INT NOT NULL,
+    operation VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    before_data JSON NULL,
+    after_data JSON NULL,
+    INDEX idx_audits_entity (entity, entity_id)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE ponds DROP COLUMN version;
+
+ALTER TABLE farms DROP COLUMN version;
@@ -0,0 +1,3 @@
+ALTER TABLE farms ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE ponds ADD COLUMN version INT NOT NULL DEFAULT 1;
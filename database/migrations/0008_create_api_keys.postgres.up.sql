@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    revoked_at TIMESTAMP(6) NULL
+);
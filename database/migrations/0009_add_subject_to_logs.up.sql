@@ -0,0 +1 @@
+ALTER TABLE logs ADD COLUMN subject VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE api_keys ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'viewer';
+
+UPDATE api_keys SET role = 'admin';
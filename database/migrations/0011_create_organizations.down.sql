@@ -0,0 +1,20 @@
+ALTER TABLE farms ADD UNIQUE INDEX name (name);
+
+ALTER TABLE ponds ADD UNIQUE INDEX name (name);
+
+ALTER TABLE api_keys
+    DROP FOREIGN KEY fk_api_keys_organization,
+    DROP COLUMN organization_id;
+
+ALTER TABLE ponds
+    DROP FOREIGN KEY fk_ponds_farm_organization,
+    DROP INDEX uq_ponds_organization_name,
+    DROP COLUMN organization_id;
+
+ALTER TABLE farms
+    DROP FOREIGN KEY fk_farms_organization,
+    DROP INDEX uq_farms_organization_name,
+    DROP INDEX uq_farms_id_organization,
+    DROP COLUMN organization_id;
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,11 @@
+ALTER TABLE farms ADD CONSTRAINT farms_name_key UNIQUE (name);
+
+ALTER TABLE ponds ADD CONSTRAINT ponds_name_key UNIQUE (name);
+
+ALTER TABLE api_keys DROP COLUMN organization_id;
+
+ALTER TABLE ponds DROP COLUMN organization_id;
+
+ALTER TABLE farms DROP COLUMN organization_id;
+
+DROP TABLE IF EXISTS organizations;
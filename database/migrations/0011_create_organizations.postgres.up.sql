@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL
+);
+
+INSERT INTO organizations (id, name, created_at) VALUES (1, 'default', CURRENT_TIMESTAMP AT TIME ZONE 'UTC');
+
+SELECT setval(pg_get_serial_sequence('organizations', 'id'), 1);
+
+ALTER TABLE farms ADD COLUMN organization_id INT NOT NULL DEFAULT 1;
+
+ALTER TABLE farms ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE farms DROP CONSTRAINT farms_name_key;
+
+ALTER TABLE farms ADD CONSTRAINT uq_farms_organization_name UNIQUE (organization_id, name);
+
+ALTER TABLE farms ADD CONSTRAINT uq_farms_id_organization UNIQUE (id, organization_id);
+
+ALTER TABLE farms ADD CONSTRAINT fk_farms_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
+
+ALTER TABLE ponds ADD COLUMN organization_id INT NOT NULL DEFAULT 1;
+
+ALTER TABLE ponds ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE ponds DROP CONSTRAINT ponds_name_key;
+
+ALTER TABLE ponds ADD CONSTRAINT uq_ponds_organization_name UNIQUE (organization_id, name);
+
+ALTER TABLE ponds ADD CONSTRAINT fk_ponds_farm_organization FOREIGN KEY (farm_id, organization_id) REFERENCES farms(id, organization_id) ON DELETE RESTRICT;
+
+ALTER TABLE api_keys ADD COLUMN organization_id INT NOT NULL DEFAULT 1;
+
+ALTER TABLE api_keys ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE api_keys ADD CONSTRAINT fk_api_keys_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
@@ -0,0 +1,52 @@
+DROP TABLE IF EXISTS farms_old;
+
+DROP TABLE IF EXISTS ponds_old;
+
+DROP TABLE IF EXISTS api_keys_old;
+
+CREATE TABLE farms_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1
+);
+
+INSERT INTO farms_old (id, name, deleted_at, version) SELECT id, name, deleted_at, version FROM farms;
+
+CREATE TABLE ponds_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    farm_id INT NOT NULL,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1,
+    FOREIGN KEY (farm_id) REFERENCES farms(id) ON DELETE RESTRICT
+);
+
+INSERT INTO ponds_old (id, name, farm_id, deleted_at, version) SELECT id, name, farm_id, deleted_at, version FROM ponds;
+
+CREATE TABLE api_keys_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL,
+    revoked_at DATETIME NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'viewer'
+);
+
+INSERT INTO api_keys_old (id, name, prefix, key_hash, scopes, created_at, revoked_at, role) SELECT id, name, prefix, key_hash, scopes, created_at, revoked_at, role FROM api_keys;
+
+DROP TABLE api_keys;
+
+DROP TABLE ponds;
+
+DROP TABLE farms;
+
+ALTER TABLE farms_old RENAME TO farms;
+
+ALTER TABLE ponds_old RENAME TO ponds;
+
+ALTER TABLE api_keys_old RENAME TO api_keys;
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,61 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+INSERT INTO organizations (id, name, created_at) VALUES (1, 'default', CURRENT_TIMESTAMP);
+
+CREATE TABLE farms_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1,
+    organization_id INT NOT NULL,
+    UNIQUE (organization_id, name),
+    UNIQUE (id, organization_id),
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT
+);
+
+INSERT INTO farms_new (id, name, deleted_at, version, organization_id) SELECT id, name, deleted_at, version, 1 FROM farms;
+
+DROP TABLE farms;
+
+ALTER TABLE farms_new RENAME TO farms;
+
+CREATE TABLE ponds_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    farm_id INT NOT NULL,
+    deleted_at DATETIME NULL,
+    version INT NOT NULL DEFAULT 1,
+    organization_id INT NOT NULL,
+    UNIQUE (organization_id, name),
+    FOREIGN KEY (farm_id) REFERENCES farms(id) ON DELETE RESTRICT,
+    FOREIGN KEY (farm_id, organization_id) REFERENCES farms(id, organization_id) ON DELETE RESTRICT
+);
+
+INSERT INTO ponds_new (id, name, farm_id, deleted_at, version, organization_id) SELECT id, name, farm_id, deleted_at, version, 1 FROM ponds;
+
+DROP TABLE ponds;
+
+ALTER TABLE ponds_new RENAME TO ponds;
+
+CREATE TABLE api_keys_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL,
+    revoked_at DATETIME NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'viewer',
+    organization_id INT NOT NULL,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT
+);
+
+INSERT INTO api_keys_new (id, name, prefix, key_hash, scopes, created_at, revoked_at, role, organization_id) SELECT id, name, prefix, key_hash, scopes, created_at, revoked_at, role, 1 FROM api_keys;
+
+DROP TABLE api_keys;
+
+ALTER TABLE api_keys_new RENAME TO api_keys;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    created_at DATETIME(6) NOT NULL
+);
+
+INSERT INTO organizations (id, name, created_at) VALUES (1, 'default', CURRENT_TIMESTAMP(6));
+
+ALTER TABLE farms
+    ADD COLUMN organization_id INT NOT NULL DEFAULT 1,
+    DROP INDEX name,
+    ADD UNIQUE INDEX uq_farms_organization_name (organization_id, name),
+    ADD UNIQUE INDEX uq_farms_id_organization (id, organization_id),
+    ADD CONSTRAINT fk_farms_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
+
+ALTER TABLE farms ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE ponds
+    ADD COLUMN organization_id INT NOT NULL DEFAULT 1,
+    DROP INDEX name,
+    ADD UNIQUE INDEX uq_ponds_organization_name (organization_id, name),
+    ADD CONSTRAINT fk_ponds_farm_organization FOREIGN KEY (farm_id, organization_id) REFERENCES farms(id, organization_id) ON DELETE RESTRICT;
+
+ALTER TABLE ponds ALTER COLUMN organization_id DROP DEFAULT;
+
+ALTER TABLE api_keys
+    ADD COLUMN organization_id INT NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_api_keys_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
+
+ALTER TABLE api_keys ALTER COLUMN organization_id DROP DEFAULT;
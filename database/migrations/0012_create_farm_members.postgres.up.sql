@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS farm_members (
+    farm_id INT NOT NULL REFERENCES farms(id) ON DELETE CASCADE,
+    subject VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    PRIMARY KEY (farm_id, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_farm_members_subject ON farm_members (subject);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS farm_members (
+    farm_id INT NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (farm_id, subject),
+    FOREIGN KEY (farm_id) REFERENCES farms(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_farm_members_subject ON farm_members (subject);
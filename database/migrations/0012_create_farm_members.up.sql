@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS farm_members (
+    farm_id INT NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (farm_id, subject),
+    INDEX idx_farm_members_subject (subject),
+    CONSTRAINT fk_farm_members_farm FOREIGN KEY (farm_id) REFERENCES farms(id) ON DELETE CASCADE
+);
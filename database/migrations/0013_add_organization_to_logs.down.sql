@@ -0,0 +1,3 @@
+ALTER TABLE logs
+    DROP INDEX idx_logs_organization_id,
+    DROP COLUMN organization_id;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_logs_organization_id;
+
+ALTER TABLE logs DROP COLUMN organization_id;
@@ -0,0 +1,5 @@
+ALTER TABLE logs ADD COLUMN organization_id INT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_logs_organization_id ON logs (organization_id);
+
+UPDATE logs SET organization_id = 1;
@@ -0,0 +1,5 @@
+ALTER TABLE logs
+    ADD COLUMN organization_id INT NULL,
+    ADD INDEX idx_logs_organization_id (organization_id);
+
+UPDATE logs SET organization_id = 1;
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const anonymousActor = "anonymous"
+
+// actor identifies who performs a request by the member subject RequireScope
+// authenticated, never by what the client claims. Unlike API key names and
+// bare token subjects, member subjects tell every key and issuer apart.
+func actor(c *gin.Context) string {
+	if member := c.GetString(ContextMember); member != "" {
+		return member
+	}
+	return anonymousActor
+}
+
+// respondHistory writes the audit trail of an entity the caller found, empty
+// for entities created before audits were recorded
+func respondHistory(auditRepository repository.AuditRepository, c *gin.Context, entity string, entityId int) {
+	audits, err := auditRepository.GetByEntity(entity, entityId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to fetch history",
+		})
+		return
+	}
+
+	// Success
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "History fetched successfully",
+		"data":    audits,
+	})
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
+	"github.com/gin-gonic/gin"
+)
+
+// ApiKeyHeader carries the API key of a request
+const ApiKeyHeader = "X-API-Key"
+
+const bearerPrefix = "Bearer "
+
+// Gin context keys of the authenticated request
+const (
+	// ContextSubject is the subject of the bearer token or the name of the API
+	// key
+	ContextSubject = "subject"
+	// ContextMember is the farm member subject of the API key or the bearer
+	// token, see model.FarmMember
+	ContextMember = "member"
+	// ContextClaims is the jwt.MapClaims of the bearer token
+	ContextClaims = "claims"
+	// ContextRole is the role of the API key or the "role" claim of the
+	// bearer token
+	ContextRole = "role"
+	// ContextOrganization is the organization id of the API key or the
+	// organization named by the "org" claim of the bearer token
+	ContextOrganization = "organization"
+	// contextApiKey is the *model.ApiKey of the request
+	contextApiKey = "api_key"
+)
+
+type AuthHandler struct {
+	apiKeyRepository       repository.ApiKeyRepository
+	organizationRepository repository.OrganizationRepository
+	jwtVerifier            *utility.JwtVerifier
+}
+
+func NewAuthHandler(
+	apiKeyRepository repository.ApiKeyRepository,
+	organizationRepository repository.OrganizationRepository,
+	jwtVerifier *utility.JwtVerifier,
+) *AuthHandler {
+	return &AuthHandler{
+		apiKeyRepository:       apiKeyRepository,
+		organizationRepository: organizationRepository,
+		jwtVerifier:            jwtVerifier,
+	}
+}
+
+// RequireScope is a middleware guarding the routes of a scope group with
+// either an API key or a bearer token. GET and HEAD requests need the read
+// scope of group and every other method the write scope. The request is then
+// confined to the organization of the key or token.
+func (h *AuthHandler) RequireScope(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var scopes []string
+		var credential string
+		if key := c.GetHeader(ApiKeyHeader); key != "" {
+			// Unknown or revoked key
+			apiKey, _ := h.apiKeyRepository.GetActiveByHash(utility.HashApiKey(key))
+			if apiKey == nil {
+				respondUnauthorized(c, "Invalid API key")
+				return
+			}
+			scopes = strings.Split(apiKey.Scopes, ",")
+			credential = "API key"
+			c.Set(contextApiKey, apiKey)
+			c.Set(ContextSubject, apiKey.Name)
+			c.Set(ContextMember, model.ApiKeyMember(apiKey.ID))
+			c.Set(ContextRole, apiKey.Role)
+			c.Set(ContextOrganization, apiKey.OrganizationID)
+		} else if authorization := c.GetHeader("Authorization"); strings.HasPrefix(authorization, bearerPrefix) {
+			// Invalid, expired or foreign token
+			claims, err := h.jwtVerifier.Verify(strings.TrimPrefix(authorization, bearerPrefix))
+			if err != nil {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				respondUnauthorized(c, "Invalid bearer token")
+				return
+			}
+			scope, _ := claims["scope"].(string)
+			scopes = strings.Fields(scope)
+			credential = "Bearer token"
+			issuer, _ := claims.GetIssuer()
+			subject, _ := claims.GetSubject()
+			role, _ := claims["role"].(string)
+			c.Set(ContextSubject, subject)
+			c.Set(ContextMember, model.TokenMember(issuer, subject))
+			c.Set(ContextClaims, claims)
+			c.Set(ContextRole, role)
+
+			// Missing or unknown organization
+			organizationName, _ := claims["org"].(string)
+			if organizationName == "" {
+				respondForbidden(c, "Bearer token lacks the org claim")
+				return
+			}
+			organization, _ := h.organizationRepository.GetByName(organizationName)
+			if organization == nil {
+				respondForbidden(c, "Unknown organization "+organizationName)
+				return
+			}
+			c.Set(ContextOrganization, organization.ID)
+		} else {
+			// Missing credentials
+			c.Header("WWW-Authenticate", "Bearer")
+			respondUnauthorized(c, "Missing API key or bearer token")
+			return
+		}
+
+		// Missing scope
+		write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead
+		if !model.ScopesAllow(scopes, group, write) {
+			access := model.ScopeRead
+			if write {
+				access = model.ScopeWrite
+			}
+			respondForbidden(c, credential+" lacks the "+model.Scope(group, access)+" scope")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func respondUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"code":    http.StatusUnauthorized,
+		"status":  "error",
+		"message": message,
+	})
+}
+
+func respondForbidden(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"code":    http.StatusForbidden,
+		"status":  "error",
+		"message": message,
+	})
+}
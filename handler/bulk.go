@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// bindBulkQuery reads the atomic flag, which defaults to all-or-nothing
+func bindBulkQuery(c *gin.Context) (bool, bool) {
+	atomic, err := strconv.ParseBool(c.DefaultQuery("atomic", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request query",
+		})
+		return false, false
+	}
+	return atomic, true
+}
+
+// bindBulk reads the atomic flag and the items of a bulk request on resource,
+// and checks the role of the request may perform their operations
+func bindBulk[I any](c *gin.Context, resource string, operation func(I) model.BulkOperation) ([]I, bool, bool) {
+	// Get query atomic
+	atomic, ok := bindBulkQuery(c)
+	if !ok {
+		return nil, false, false
+	}
+
+	// Bind payload
+	var items []I
+	if err := c.Bind(&items); err != nil || len(items) == 0 || len(items) > model.MaxBulkSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return nil, false, false
+	}
+
+	// Role policy
+	operations := make([]model.BulkOperation, len(items))
+	for i, item := range items {
+		operations[i] = operation(item)
+	}
+	actions, ok := bulkActions(c, resource, operations)
+	if !ok || !authorize(c, resource, actions...) {
+		return nil, false, false
+	}
+	return items, atomic, true
+}
+
+// bulkBatch holds the result of every item of a bulk request on resource, and
+// the ids and names of the items validated so far, to find duplicates within
+// the batch
+type bulkBatch struct {
+	resource string
+	entity   string
+	atomic   bool
+	results  []model.BulkResult
+	ids      map[int]bool
+	names    map[string]bool
+}
+
+func newBulkBatch(resource string, atomic bool, operations []model.BulkOperation) *bulkBatch {
+	results := make([]model.BulkResult, len(operations))
+	for i, operation := range operations {
+		results[i] = model.BulkResult{Index: i, Operation: operation}
+	}
+	return &bulkBatch{
+		resource: resource,
+		entity:   strings.ToUpper(resource[:1]) + resource[1:],
+		atomic:   atomic,
+		results:  results,
+		ids:      make(map[int]bool),
+		names:    make(map[string]bool),
+	}
+}
+
+// fail marks item i as failed with code
+func (b *bulkBatch) fail(i int, code int, message string) {
+	bulkError(&b.results[i], code, message)
+}
+
+// checkPayload reports whether item i has a known operation, and a name
+// unless it is a delete
+func (b *bulkBatch) checkPayload(i int, name string) bool {
+	operation := b.results[i].Operation
+	if !operation.IsValid() || (operation != model.BulkDelete && name == "") {
+		b.fail(i, http.StatusBadRequest, "Invalid request payload")
+		return false
+	}
+	return true
+}
+
+// checkTarget reports whether item i, an update or delete of id, targets an
+// existing resource no earlier item of the batch targets
+func (b *bulkBatch) checkTarget(i int, id int, exists bool) bool {
+	if !exists {
+		b.fail(i, http.StatusNotFound, "Data Not Found")
+		return false
+	}
+	if b.ids[id] {
+		b.fail(i, http.StatusConflict, b.entity+" is duplicated in the request")
+		return false
+	}
+	b.ids[id] = true
+	return true
+}
+
+// checkName reports whether no earlier item of the batch has the name of
+// item i, nor another resource than id, when exists, deleted or not
+func (b *bulkBatch) checkName(i int, id int, name string, existingId int, existingDeletedAt *time.Time, exists bool) bool {
+	if b.names[name] {
+		b.fail(i, http.StatusConflict, b.entity+" name is duplicated in the request")
+		return false
+	}
+	b.names[name] = true
+	if exists && (existingId != id || existingDeletedAt != nil) {
+		b.fail(i, http.StatusConflict, nameConflictMessage(b.entity, existingDeletedAt))
+		return false
+	}
+	return true
+}
+
+// valid lists the indexes of the items that passed validation, responding
+// 400 instead when an all-or-nothing batch holds an invalid item
+func (b *bulkBatch) valid(c *gin.Context) ([]int, bool) {
+	indexes := make([]int, 0, len(b.results))
+	for i, result := range b.results {
+		if result.Status == "" {
+			indexes = append(indexes, i)
+		}
+	}
+	if b.atomic && len(indexes) < len(b.results) {
+		skipBulk(b.results)
+		respondBulk(c, b.resource, b.atomic, http.StatusBadRequest, b.results)
+		return nil, false
+	}
+	return indexes, true
+}
+
+// applied records the outcome of applying item i, given its own error and
+// the error of the whole batch, reporting whether it succeeded
+func (b *bulkBatch) applied(i int, err error, batchErr error) bool {
+	if err != nil {
+		bulkApplyError(&b.results[i], err, "Failed to apply "+b.resource)
+		return false
+	}
+	if batchErr != nil {
+		return false
+	}
+	b.results[i].Status = model.BulkSuccess
+	b.results[i].Code = http.StatusOK
+	return true
+}
+
+// respond answers the applied batch, 500 with every item not failed skipped
+// when the batch failed as a whole
+func (b *bulkBatch) respond(c *gin.Context, batchErr error) {
+	if batchErr != nil {
+		skipBulk(b.results)
+		respondBulk(c, b.resource, b.atomic, http.StatusInternalServerError, b.results)
+		return
+	}
+	respondBulk(c, b.resource, b.atomic, http.StatusBadRequest, b.results)
+}
+
+// pickBulk returns the items at indexes
+func pickBulk[I any](items []I, indexes []int) []I {
+	picked := make([]I, len(indexes))
+	for j, i := range indexes {
+		picked[j] = items[i]
+	}
+	return picked
+}
+
+func bulkError(result *model.BulkResult, code int, message string) {
+	result.Status = model.BulkError
+	result.Code = code
+	result.Message = message
+}
+
+// skipBulk marks the items not yet failed as skipped, as an all-or-nothing
+// batch was not applied
+func skipBulk(results []model.BulkResult) {
+	for i := range results {
+		if results[i].Status != model.BulkError {
+			results[i].Status = model.BulkSkipped
+			results[i].Code = 0
+		}
+	}
+}
+
+// bulkApplyError maps the error of applying an item
+func bulkApplyError(result *model.BulkResult, err error, message string) {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		bulkError(result, http.StatusPreconditionFailed, "Precondition Failed")
+		return
+	}
+	if errors.Is(err, repository.ErrFarmHasPonds) {
+		bulkError(result, http.StatusConflict, "Farm still has ponds")
+		return
+	}
+	bulkError(result, http.StatusInternalServerError, message)
+}
+
+// respondBulk answers 200 when every item succeeded, 207 when a best-effort
+// batch only partially applied, and the given failure code otherwise
+func respondBulk(c *gin.Context, resource string, atomic bool, failureCode int, results []model.BulkResult) {
+	failed := false
+	for _, result := range results {
+		if result.Status != model.BulkSuccess {
+			failed = true
+			break
+		}
+	}
+
+	switch {
+	case !failed:
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"status":  "success",
+			"message": "Bulk " + resource + " applied successfully",
+			"data":    results,
+		})
+	case atomic || failureCode == http.StatusInternalServerError:
+		c.JSON(failureCode, gin.H{
+			"code":    failureCode,
+			"status":  "error",
+			"message": "Bulk " + resource + " not applied",
+			"data":    results,
+		})
+	default:
+		c.JSON(http.StatusMultiStatus, gin.H{
+			"code":    http.StatusMultiStatus,
+			"status":  "success",
+			"message": "Bulk " + resource + " partially applied",
+			"data":    results,
+		})
+	}
+}
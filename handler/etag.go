@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag is the strong entity tag of a resource version
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// matchesETag reports whether the comma separated header lists the entity tag
+// of version, or is the "*" wildcard. Weak tags only match when weak is set,
+// as If-Match requires strong comparison.
+func matchesETag(header string, version int, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch responds 412 unless the If-Match header is absent or matches
+// the current version. A missing resource never matches.
+func checkIfMatch(c *gin.Context, version int, exists bool) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || (exists && matchesETag(header, version, false)) {
+		return true
+	}
+
+	respondPreconditionFailed(c)
+	return false
+}
+
+// respondPreconditionFailed answers a write against a stale version
+func respondPreconditionFailed(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"code":    http.StatusPreconditionFailed,
+		"status":  "error",
+		"message": "Precondition Failed",
+	})
+}
+
+// checkIfNoneMatch sets the ETag header and responds 304 when the If-None-Match
+// header already matches the current version
+func checkIfNoneMatch(c *gin.Context, version int) bool {
+	c.Header("ETag", etag(version))
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !matchesETag(header, version, true) {
+		return true
+	}
+
+	c.Status(http.StatusNotModified)
+	return false
+}
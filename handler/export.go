@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	mimeNDJSON = "application/x-ndjson"
+	mimeCSV    = "text/csv"
+)
+
+// exportFlushSize is the number of records written between flushes
+const exportFlushSize = 100
+
+// exportErrorTrailer is the trailer naming the error that cut an export off
+// once its records started streaming
+const exportErrorTrailer = "X-Export-Error"
+
+// exportWriter streams records in the format negotiated from the Accept
+// header, NDJSON by default. The status and headers are only sent with the
+// first record, so an export failing before it still responds 500.
+type exportWriter struct {
+	c           *gin.Context
+	format      string
+	fileName    string
+	header      []string
+	csvWriter   *csv.Writer
+	jsonEncoder *json.Encoder
+	started     bool
+	count       int
+}
+
+// newExportWriter responds 406 when neither NDJSON nor CSV is acceptable
+func newExportWriter(c *gin.Context, fileName string, header []string) (*exportWriter, bool) {
+	format := c.NegotiateFormat(mimeNDJSON, mimeCSV)
+	if format == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{
+			"code":    http.StatusNotAcceptable,
+			"status":  "error",
+			"message": "Export is only available as " + mimeNDJSON + " or " + mimeCSV,
+		})
+		return nil, false
+	}
+	return &exportWriter{c: c, format: format, fileName: fileName, header: header}, true
+}
+
+// start writes the response headers and the CSV header row
+func (w *exportWriter) start() error {
+	w.started = true
+	w.c.Status(http.StatusOK)
+	if w.format == mimeCSV {
+		w.c.Header("Content-Type", mimeCSV+"; charset=utf-8")
+		w.c.Header("Content-Disposition", `attachment; filename="`+w.fileName+`.csv"`)
+		w.csvWriter = csv.NewWriter(w.c.Writer)
+		w.csvWriter.Write(w.header)
+		return w.flush()
+	}
+	w.c.Header("Content-Type", mimeNDJSON)
+	w.c.Header("Content-Disposition", `attachment; filename="`+w.fileName+`.ndjson"`)
+	w.jsonEncoder = json.NewEncoder(w.c.Writer)
+	return nil
+}
+
+// Write writes record as a JSON line, or its row as a CSV record
+func (w *exportWriter) Write(record interface{}, row []string) error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if w.csvWriter != nil {
+		err = w.csvWriter.Write(row)
+	} else {
+		err = w.jsonEncoder.Encode(record)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.count++
+	if w.count%exportFlushSize == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close flushes the remaining records. An export failing before its first
+// record responds 500. Once records are streaming the status is already
+// sent, so the export is marked incomplete instead, by the X-Export-Error
+// trailer and, for NDJSON, a last line holding the error.
+func (w *exportWriter) Close(err error) {
+	if err == nil && !w.started {
+		err = w.start()
+	}
+	if w.started {
+		if errFlush := w.flush(); err == nil {
+			err = errFlush
+		}
+	}
+	if err == nil {
+		return
+	}
+
+	log.WithFields(log.Fields{"error": err, "route": w.c.FullPath(), "records": w.count}).Warn("Failed to export")
+	message := "Failed to export " + w.fileName
+	if !w.started {
+		w.c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": message,
+		})
+		return
+	}
+	if w.jsonEncoder != nil {
+		w.jsonEncoder.Encode(gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": message,
+		})
+	}
+	w.c.Writer.Header().Set(http.TrailerPrefix+exportErrorTrailer, message)
+}
+
+// flush sends the buffered records, returning the error of a CSV record
+// that failed to write
+func (w *exportWriter) flush() error {
+	if w.csvWriter != nil {
+		w.csvWriter.Flush()
+		if err := w.csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+	w.c.Writer.Flush()
+	return nil
+}
@@ -1,311 +1,696 @@
 package handler
 
 import (
-    "net/http"
-    "strconv"
+	"errors"
+	"net/http"
+	"strconv"
 
-    "github.com/gin-gonic/gin"
-    "github.com/WillyWilsen/Delos-Task-Assignment.git/model"
-    "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/gin-gonic/gin"
 )
 
 type FarmHandler struct {
-    farmRepository repository.FarmRepository
-    logRepository repository.LogRepository
+	farmRepository  repository.FarmRepository
+	pondRepository  repository.PondRepository
+	auditRepository repository.AuditRepository
+	strictPut       bool
 }
 
 func NewFarmHandler(
-    farmRepository repository.FarmRepository, 
-    logRepository repository.LogRepository,
+	farmRepository repository.FarmRepository,
+	pondRepository repository.PondRepository,
+	auditRepository repository.AuditRepository,
+	strictPut bool,
 ) *FarmHandler {
-    return &FarmHandler{
-        farmRepository: farmRepository,
-        logRepository: logRepository,
-    }
+	return &FarmHandler{
+		farmRepository:  farmRepository,
+		pondRepository:  pondRepository,
+		auditRepository: auditRepository,
+		strictPut:       strictPut,
+	}
+}
+
+// farms scopes the farm repository to the farms the request reaches
+func (h *FarmHandler) farms(c *gin.Context) repository.FarmRepository {
+	return scopeFarms(c, h.farmRepository)
+}
+
+// ponds scopes the pond repository to the ponds the request reaches
+func (h *FarmHandler) ponds(c *gin.Context) repository.PondRepository {
+	return scopePonds(c, h.pondRepository)
 }
 
 func (h *FarmHandler) CreateFarm(c *gin.Context) {
-    // Create log
-    log := model.Log{
-		Endpoint:  "POST /farm",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    var farm model.Farm
-
-    // Bind payload
-    if err := c.Bind(&farm); err != nil || farm.Name == "" {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request payload",
-        })
-        return
-    }
-
-    // Exist farm name
-    existFarm, _ := h.farmRepository.GetByName(farm.Name)
-    if existFarm != nil {
-        c.JSON(http.StatusConflict, gin.H{
-            "code": http.StatusConflict,
-            "status": "error",
-            "message": "Farm name already exists",
-        })
-        return
-    }
-
-    // Create farm
-    if err := h.farmRepository.Create(&farm); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create farm",
-        })
-        return
-    }
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionCreate) {
+		return
+	}
+
+	var farm model.Farm
+
+	// Bind payload
+	if err := c.Bind(&farm); err != nil || farm.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return
+	}
+	farm.DeletedAt = nil
+
+	// Exist farm name
+	existFarm, _ := h.farms(c).GetByName(farm.Name)
+	if existFarm != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"status":  "error",
+			"message": nameConflictMessage("Farm", existFarm.DeletedAt),
+		})
+		return
+	}
+
+	// Create farm
+	if err := h.farms(c).Create(&farm); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to create farm",
+		})
+		return
+	}
 
 	// Success
-    c.JSON(http.StatusOK, gin.H{
-        "code": http.StatusOK,
-        "status": "success",
-        "message": "New farm created successfully",
-        "data": farm,
-    })
+	c.Header("ETag", etag(farm.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "New farm created successfully",
+		"data":    farm,
+	})
 }
 
 func (h *FarmHandler) GetFarm(c *gin.Context) {
-    // Create log
-    log := model.Log{
-		Endpoint:  "GET /farm",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    farm, _ := h.farmRepository.Get()
-
-    // Empty farm
-    if farm == nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "code": http.StatusNotFound,
-            "status": "error",
-            "message": "Data Not Found",
-        })
-        return
-    }
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionRead) {
+		return
+	}
+
+	// Get query
+	query, ok := bindListQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request query",
+		})
+		return
+	}
+
+	farm, total, err := h.farms(c).List(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to fetch farm",
+		})
+		return
+	}
 
 	// Success
-    c.JSON(http.StatusOK, gin.H{
-        "code": http.StatusOK,
-        "status": "success",
-        "message": "Farm fetched successfully",
-        "data": farm,
-    })
+	c.JSON(http.StatusOK, gin.H{
+		"code":       http.StatusOK,
+		"status":     "success",
+		"message":    "Farm fetched successfully",
+		"data":       farm,
+		"pagination": pagination(c, query, total),
+	})
+}
+
+// ExportFarm streams every farm as NDJSON or CSV
+func (h *FarmHandler) ExportFarm(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionRead) {
+		return
+	}
+
+	writer, ok := newExportWriter(c, "farms", []string{"id", "name"})
+	if !ok {
+		return
+	}
+
+	writer.Close(h.farms(c).Export(func(farm model.Farm) error {
+		return writer.Write(farm, []string{strconv.Itoa(farm.ID), farm.Name})
+	}))
 }
 
 func (h *FarmHandler) GetFarmById(c *gin.Context) {
-    // Create log
-    log := model.Log{
-		Endpoint:  "GET /farm/:id",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    // Get param id
-    id, err := strconv.Atoi(c.Param("id"))
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request param",
-        })
-        return
-    }
-
-    farm, _ := h.farmRepository.GetById(id)
-
-    // Empty farm
-    if farm == nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "code": http.StatusNotFound,
-            "status": "error",
-            "message": "Data Not Found",
-        })
-        return
-    }
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionRead) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	farm, _ := h.farms(c).GetById(id)
+
+	// Empty farm
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+		return
+	}
+
+	// Unchanged farm
+	if !checkIfNoneMatch(c, farm.Version) {
+		return
+	}
 
 	// Success
-    c.JSON(http.StatusOK, gin.H{
-        "code": http.StatusOK,
-        "status": "success",
-        "message": "Farm fetched successfully",
-        "data": farm,
-    })
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Farm fetched successfully",
+		"data":    farm,
+	})
 }
 
 func (h *FarmHandler) UpdateFarm(c *gin.Context) {
-    // Create log
-    log := model.Log{
-		Endpoint:  "PUT /farm/:id",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    // Get param id
-    id, err := strconv.Atoi(c.Param("id"))
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request param",
-        })
-        return
-    }
-
-    // Bind payload
-    var farmPayload model.Farm
-    if err := c.Bind(&farmPayload); err != nil || farmPayload.Name == "" {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request payload",
-        })
-        return
-    }
-
-    // Exist farm name
-    existFarm, _ := h.farmRepository.GetByName(farmPayload.Name)
-    if existFarm != nil && existFarm.ID != id {
-        c.JSON(http.StatusConflict, gin.H{
-            "code": http.StatusConflict,
-            "status": "error",
-            "message": "Farm name already exists",
-        })
-        return
-    }
-
-    farm, _ := h.farmRepository.GetById(id)
-
-    // Empty farm
-    if farm == nil {
-        // Create farm
-        if err := h.farmRepository.Create(&farmPayload); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{
-                "code": http.StatusInternalServerError,
-                "status": "error",
-                "message": "Failed to create farm",
-            })
-            return
-        }
-
-        // Success
-        c.JSON(http.StatusOK, gin.H{
-            "code": http.StatusOK,
-            "status": "success",
-            "message": "Data Not Found. New farm created successfully",
-            "data": farmPayload,
-        })
-    } else {
-        // Update farm
-        if err := h.farmRepository.Update(farm.ID, &farmPayload); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{
-                "code": http.StatusInternalServerError,
-                "status": "error",
-                "message": "Failed to create farm",
-            })
-            return
-        }
-        farmPayload.ID = farm.ID
-
-        // Success
-        c.JSON(http.StatusOK, gin.H{
-            "code": http.StatusOK,
-            "status": "success",
-            "message": "Farm updated successfully",
-            "data": farmPayload,
-        })
-    }
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionUpdate) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	// Bind payload
+	var farmPayload model.Farm
+	if err := c.Bind(&farmPayload); err != nil || farmPayload.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return
+	}
+	farmPayload.DeletedAt = nil
+
+	// Exist farm name
+	existFarm, _ := h.farms(c).GetByName(farmPayload.Name)
+	if existFarm != nil && (existFarm.ID != id || existFarm.DeletedAt != nil) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"status":  "error",
+			"message": nameConflictMessage("Farm", existFarm.DeletedAt),
+		})
+		return
+	}
+
+	farm, _ := h.farms(c).GetById(id)
+
+	// Empty farm
+	if farm == nil {
+		// If-Match precondition
+		if !checkIfMatch(c, 0, false) {
+			return
+		}
+
+		// Deleted farm
+		deletedFarm, _ := h.farms(c).GetDeletedById(id)
+		if deletedFarm != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"code":    http.StatusConflict,
+				"status":  "error",
+				"message": "Farm is deleted, restore it instead",
+			})
+			return
+		}
+
+		// Strict PUT
+		if h.strictPut {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"status":  "error",
+				"message": "Data Not Found",
+			})
+			return
+		}
+
+		// Role policy
+		if !authorize(c, model.ResourceFarm, model.ActionCreate) {
+			return
+		}
+
+		// Create farm at the requested id
+		farmPayload.ID = id
+		if err := h.farms(c).Create(&farmPayload); errors.Is(err, repository.ErrIdTaken) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"status":  "error",
+				"message": "Data Not Found",
+			})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"status":  "error",
+				"message": "Failed to create farm",
+			})
+			return
+		}
+
+		// Success
+		c.Header("Location", c.Request.URL.Path)
+		c.Header("ETag", etag(farmPayload.Version))
+		c.JSON(http.StatusCreated, gin.H{
+			"code":    http.StatusCreated,
+			"status":  "success",
+			"message": "Data Not Found. New farm created successfully",
+			"data":    farmPayload,
+		})
+	} else {
+		// If-Match precondition
+		if !checkIfMatch(c, farm.Version, true) {
+			return
+		}
+
+		// Update farm
+		farmPayload.Version = farm.Version
+		if err := h.farms(c).Update(farm.ID, &farmPayload); errors.Is(err, repository.ErrVersionConflict) {
+			respondPreconditionFailed(c)
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"status":  "error",
+				"message": "Failed to create farm",
+			})
+			return
+		}
+		farmPayload.ID = farm.ID
+
+		// Success
+		c.Header("ETag", etag(farmPayload.Version))
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"status":  "success",
+			"message": "Farm updated successfully",
+			"data":    farmPayload,
+		})
+	}
+}
+
+func (h *FarmHandler) PatchFarm(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionUpdate) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	farm, _ := h.farms(c).GetById(id)
+
+	// Empty farm
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+		return
+	}
+
+	// If-Match precondition
+	if !checkIfMatch(c, farm.Version, true) {
+		return
+	}
+
+	// Bind merge patch payload
+	var farmPayload model.Farm
+	if err := bindMergePatch(c, farm, &farmPayload); err != nil || farmPayload.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return
+	}
+	farmPayload.ID = farm.ID
+	farmPayload.DeletedAt = nil
+	farmPayload.Version = farm.Version
+
+	// Exist farm name
+	existFarm, _ := h.farms(c).GetByName(farmPayload.Name)
+	if existFarm != nil && (existFarm.ID != id || existFarm.DeletedAt != nil) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"status":  "error",
+			"message": nameConflictMessage("Farm", existFarm.DeletedAt),
+		})
+		return
+	}
+
+	// Update farm
+	if err := h.farms(c).Update(farm.ID, &farmPayload); errors.Is(err, repository.ErrVersionConflict) {
+		respondPreconditionFailed(c)
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to update farm",
+		})
+		return
+	}
+
+	// Success
+	c.Header("ETag", etag(farmPayload.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Farm updated successfully",
+		"data":    farmPayload,
+	})
 }
 
 func (h *FarmHandler) DeleteFarm(c *gin.Context) {
-    // Create log
-    log := model.Log{
-		Endpoint:  "DELETE /farm/:id",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    // Get param id
-    id, err := strconv.Atoi(c.Param("id"))
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request param",
-        })
-        return
-    }
-
-    farm, _ := h.farmRepository.GetById(id)
-
-    // Empty farm
-    if farm == nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "code": http.StatusNotFound,
-            "status": "error",
-            "message": "Data Not Found",
-        })
-    } else {
-        // Delete farm
-        if err := h.farmRepository.Delete(farm); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{
-                "code": http.StatusInternalServerError,
-                "status": "error",
-                "message": "Failed to delete farm",
-            })
-            return
-        }
-
-        // Success
-        c.JSON(http.StatusOK, gin.H{
-            "code": http.StatusOK,
-            "status": "success",
-            "message": "Farm deleted successfully",
-        })
-    }
-}
\ No newline at end of file
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionDelete) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	// Get query cascade
+	cascade, err := strconv.ParseBool(c.DefaultQuery("cascade", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request query",
+		})
+		return
+	}
+
+	farm, _ := h.farms(c).GetById(id)
+
+	// Empty farm
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+		return
+	}
+
+	// If-Match precondition
+	if !checkIfMatch(c, farm.Version, true) {
+		return
+	}
+
+	// Dependent ponds
+	ponds, err := h.ponds(c).GetByFarmId(farm.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to delete farm",
+		})
+		return
+	}
+	if len(ponds) > 0 && !cascade {
+		respondFarmHasPonds(c, ponds)
+		return
+	}
+
+	// Delete farm
+	if cascade {
+		err = h.farms(c).DeleteWithPonds(farm)
+	} else {
+		err = h.farms(c).Delete(farm)
+	}
+	if errors.Is(err, repository.ErrFarmHasPonds) {
+		// A pond was created since the check
+		ponds, _ = h.ponds(c).GetByFarmId(farm.ID)
+		respondFarmHasPonds(c, ponds)
+		return
+	} else if errors.Is(err, repository.ErrVersionConflict) {
+		respondPreconditionFailed(c)
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to delete farm",
+		})
+		return
+	}
+
+	// Success
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Farm deleted successfully",
+	})
+}
+
+func (h *FarmHandler) RestoreFarm(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionRestore) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	farm, _ := h.farms(c).GetDeletedById(id)
+
+	// Empty deleted farm
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Deleted Data Not Found",
+		})
+		return
+	}
+
+	// If-Match precondition
+	if !checkIfMatch(c, farm.Version, true) {
+		return
+	}
+
+	// Restore farm
+	if err := h.farms(c).Restore(farm); errors.Is(err, repository.ErrVersionConflict) {
+		respondPreconditionFailed(c)
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to restore farm",
+		})
+		return
+	}
+
+	// Success
+	c.Header("ETag", etag(farm.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Farm restored successfully",
+		"data":    farm,
+	})
+}
+
+func (h *FarmHandler) GetFarmHistory(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionRead) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	// Farm of another organization
+	farm, _ := h.farms(c).GetById(id)
+	if farm == nil {
+		farm, _ = h.farms(c).GetDeletedById(id)
+	}
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+		return
+	}
+
+	respondHistory(h.auditRepository, c, model.AuditEntityFarm, id)
+}
+
+// BulkFarm validates every item against the batch and the stored farms up
+// front, then applies the valid ones in a single transaction
+func (h *FarmHandler) BulkFarm(c *gin.Context) {
+	items, atomic, ok := bindBulk(c, model.ResourceFarm, func(item model.FarmBulkItem) model.BulkOperation {
+		return item.Operation
+	})
+	if !ok {
+		return
+	}
+
+	// Referenced farms and the ponds of deleted farms
+	names := make([]string, 0, len(items))
+	ids := make([]int, 0, len(items))
+	deleteIds := make([]int, 0, len(items))
+	operations := make([]model.BulkOperation, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+		ids = append(ids, item.ID)
+		operations = append(operations, item.Operation)
+		if item.Operation == model.BulkDelete {
+			deleteIds = append(deleteIds, item.ID)
+		}
+	}
+	existFarms, errNames := h.farms(c).GetByNames(names)
+	farms, errIds := h.farms(c).GetByIds(ids)
+	ponds, errPonds := h.ponds(c).GetByFarmIds(deleteIds)
+	if errNames != nil || errIds != nil || errPonds != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to apply bulk farm",
+		})
+		return
+	}
+	farmsByName := make(map[string]model.Farm, len(existFarms))
+	for _, farm := range existFarms {
+		farmsByName[farm.Name] = farm
+	}
+	farmsById := make(map[int]model.Farm, len(farms))
+	for _, farm := range farms {
+		farmsById[farm.ID] = farm
+	}
+	pondCount := make(map[int]int)
+	for _, pond := range ponds {
+		pondCount[pond.FarmID]++
+	}
+
+	// Validate items
+	batch := newBulkBatch(model.ResourceFarm, atomic, operations)
+	for i := range items {
+		item := &items[i]
+		item.DeletedAt = nil
+		if !batch.checkPayload(i, item.Name) {
+			continue
+		}
+
+		if item.Operation == model.BulkCreate {
+			item.ID = 0
+		} else {
+			farm, ok := farmsById[item.ID]
+			if !batch.checkTarget(i, item.ID, ok) {
+				continue
+			}
+			item.Version = farm.Version
+
+			if item.Operation == model.BulkDelete {
+				item.Farm = farm
+				if pondCount[farm.ID] > 0 {
+					batch.fail(i, http.StatusConflict, "Farm still has ponds")
+				}
+				continue
+			}
+		}
+
+		// Exist farm name
+		existFarm, ok := farmsByName[item.Name]
+		batch.checkName(i, item.ID, item.Name, existFarm.ID, existFarm.DeletedAt, ok)
+	}
+	validIndexes, ok := batch.valid(c)
+	if !ok {
+		return
+	}
+
+	// Apply farms
+	validItems := pickBulk(items, validIndexes)
+	errs, err := h.farms(c).Bulk(validItems, atomic)
+	for j, i := range validIndexes {
+		item := validItems[j]
+		if !batch.applied(i, errs[j], err) {
+			continue
+		}
+		if item.Operation != model.BulkDelete {
+			batch.results[i].Data = item.Farm
+		}
+	}
+	batch.respond(c, err)
+}
+
+// respondFarmHasPonds answers the deletion of a farm still holding ponds,
+// listing them
+func respondFarmHasPonds(c *gin.Context, ponds []model.Pond) {
+	c.JSON(http.StatusConflict, gin.H{
+		"code":    http.StatusConflict,
+		"status":  "error",
+		"message": "Farm still has ponds",
+		"data":    ponds,
+	})
+}
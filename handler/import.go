@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrImportMissingFarmColumn = errors.New("import file has no farm column")
+	ErrImportEmpty             = errors.New("import file has no rows")
+	ErrImportTooLarge          = errors.New("import file has too many rows")
+)
+
+type ImportHandler struct {
+	farmRepository repository.FarmRepository
+	pondRepository repository.PondRepository
+}
+
+func NewImportHandler(
+	farmRepository repository.FarmRepository,
+	pondRepository repository.PondRepository,
+) *ImportHandler {
+	return &ImportHandler{
+		farmRepository: farmRepository,
+		pondRepository: pondRepository,
+	}
+}
+
+// ImportFarmPond imports the farms and ponds of an uploaded CSV or XLSX file
+func (h *ImportHandler) ImportFarmPond(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceFarm, model.ActionCreate) || !authorize(c, model.ResourcePond, model.ActionCreate) {
+		return
+	}
+
+	// Get query dry run
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request query",
+		})
+		return
+	}
+
+	// Read file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, model.MaxImportSize)
+	var rows []model.ImportRow
+	fileHeader, err := c.FormFile("file")
+	if err == nil {
+		file, errOpen := fileHeader.Open()
+		if errOpen != nil {
+			err = errOpen
+		} else {
+			defer file.Close()
+			rows, err = ParseImportRows(file, fileHeader.Filename)
+		}
+	}
+	var errTooLarge *http.MaxBytesError
+	if errors.As(err, &errTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"code":    http.StatusRequestEntityTooLarge,
+			"status":  "error",
+			"message": "Import file too large",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid import file",
+		})
+		return
+	}
+
+	results, valid, err := h.importRows(rows, dryRun, scopeFarms(c, h.farmRepository), scopePonds(c, h.pondRepository))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to import farm and pond",
+		})
+		return
+	}
+
+	// Invalid rows
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Import rejected, nothing was committed",
+			"data":    results,
+		})
+		return
+	}
+
+	// Success
+	message := "Farm and pond imported successfully"
+	if dryRun {
+		message = "Import validated, nothing was committed"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": message,
+		"data":    results,
+	})
+}
+
+// ParseImportRows reads the farm and pond columns of a spreadsheet whose first
+// row is a header, skipping blank rows. It stops reading as soon as the
+// spreadsheet holds more than model.MaxImportRows rows.
+func ParseImportRows(reader io.Reader, fileName string) ([]model.ImportRow, error) {
+	cell := func(record []string, column int) string {
+		if column < 0 || column >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[column])
+	}
+
+	header := true
+	line := 0
+	farmColumn, pondColumn := -1, -1
+	rows := make([]model.ImportRow, 0)
+	err := utility.ReadSpreadsheet(reader, fileName, func(record []string) error {
+		line++
+		if header {
+			header = false
+			for i, column := range record {
+				// Excel starts the files it saves as CSV UTF-8 with a byte order mark
+				if i == 0 {
+					column = strings.TrimPrefix(column, "\ufeff")
+				}
+				switch strings.ToLower(strings.TrimSpace(column)) {
+				case "farm":
+					farmColumn = i
+				case "pond":
+					pondColumn = i
+				}
+			}
+			if farmColumn < 0 {
+				return ErrImportMissingFarmColumn
+			}
+			return nil
+		}
+
+		row := model.ImportRow{
+			Row:  line,
+			Farm: cell(record, farmColumn),
+			Pond: cell(record, pondColumn),
+		}
+		if row.Farm == "" && row.Pond == "" {
+			return nil
+		}
+		if len(rows) == model.MaxImportRows {
+			return ErrImportTooLarge
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	return rows, nil
+}
+
+// Import runs the CreateFarm and CreatePond validation on every row, resolving
+// ponds to stored farms or farms of the same import by name. Unless a row is
+// invalid or dryRun is set, it then creates the farms and ponds in a single
+// transaction, audited under actor. Every farm and pond belongs to
+// organizationId.
+func (h *ImportHandler) Import(rows []model.ImportRow, dryRun bool, actor string, organizationId int) ([]model.ImportResult, bool, error) {
+	return h.importRows(rows, dryRun, h.farmRepository.InOrganization(organizationId).AuditedBy(actor), h.pondRepository.InOrganization(organizationId).AuditedBy(actor))
+}
+
+// importRows imports rows through repositories scoped to the caller, which
+// may only add ponds to the stored farms it reaches
+func (h *ImportHandler) importRows(
+	rows []model.ImportRow,
+	dryRun bool,
+	farmRepository repository.FarmRepository,
+	pondRepository repository.PondRepository,
+) ([]model.ImportResult, bool, error) {
+	// Referenced farms and ponds
+	farmNames := make([]string, 0, len(rows))
+	pondNames := make([]string, 0, len(rows))
+	for _, row := range rows {
+		farmNames = append(farmNames, row.Farm)
+		pondNames = append(pondNames, row.Pond)
+	}
+	existFarms, err := farmRepository.GetByNames(farmNames)
+	if err != nil {
+		return nil, false, err
+	}
+	existPonds, err := pondRepository.GetByNames(pondNames)
+	if err != nil {
+		return nil, false, err
+	}
+	farmsByName := make(map[string]model.Farm, len(existFarms))
+	existFarmIds := make([]int, 0, len(existFarms))
+	for _, farm := range existFarms {
+		farmsByName[farm.Name] = farm
+		existFarmIds = append(existFarmIds, farm.ID)
+	}
+	reachFarms, err := farmRepository.GetByIds(existFarmIds)
+	if err != nil {
+		return nil, false, err
+	}
+	reachable := make(map[int]bool, len(reachFarms))
+	for _, farm := range reachFarms {
+		reachable[farm.ID] = true
+	}
+	pondsByName := make(map[string]model.Pond, len(existPonds))
+	for _, pond := range existPonds {
+		pondsByName[pond.Name] = pond
+	}
+
+	// Validate rows
+	results := make([]model.ImportResult, len(rows))
+	valid := true
+	resolvedFarms := make(map[string]*model.Farm)
+	declaredFarms := make(map[string]bool)
+	importedPonds := make(map[string]bool)
+	newFarms := make([]*model.Farm, 0)
+	newPonds := make([]model.ImportPond, 0)
+	for i, row := range rows {
+		result := &results[i]
+		*result = model.ImportResult{Row: row.Row, Farm: row.Farm, Pond: row.Pond, Status: model.BulkSuccess}
+		fail := func(message string) {
+			result.Status = model.BulkError
+			result.Message = message
+			valid = false
+		}
+
+		if row.Farm == "" {
+			fail("Invalid request payload")
+			continue
+		}
+		existFarm, exists := farmsByName[row.Farm]
+		if exists && existFarm.DeletedAt != nil {
+			fail(nameConflictMessage("Farm", existFarm.DeletedAt))
+			continue
+		}
+
+		if row.Pond == "" {
+			// Exist farm name
+			if exists {
+				fail(nameConflictMessage("Farm", nil))
+				continue
+			}
+			if declaredFarms[row.Farm] {
+				fail("Farm name is duplicated in the file")
+				continue
+			}
+			declaredFarms[row.Farm] = true
+		} else {
+			// Exist pond name
+			if importedPonds[row.Pond] {
+				fail("Pond name is duplicated in the file")
+				continue
+			}
+			importedPonds[row.Pond] = true
+			if existPond, ok := pondsByName[row.Pond]; ok {
+				fail(nameConflictMessage("Pond", existPond.DeletedAt))
+				continue
+			}
+
+			// Farm out of reach
+			if exists && !reachable[existFarm.ID] {
+				fail("Farm Data Not Found")
+				continue
+			}
+		}
+
+		// Resolve farm
+		farm, ok := resolvedFarms[row.Farm]
+		if !ok {
+			if exists {
+				farm = &existFarm
+			} else {
+				farm = &model.Farm{Name: row.Farm}
+				newFarms = append(newFarms, farm)
+			}
+			resolvedFarms[row.Farm] = farm
+		}
+		if row.Pond != "" {
+			newPonds = append(newPonds, model.ImportPond{Pond: &model.Pond{Name: row.Pond}, Farm: farm})
+		}
+	}
+
+	// Invalid rows reject the whole import
+	if !valid {
+		for i := range results {
+			if results[i].Status == model.BulkSuccess {
+				results[i].Status = model.BulkSkipped
+			}
+		}
+		return results, false, nil
+	}
+	if dryRun {
+		return results, true, nil
+	}
+
+	// Create farms and ponds
+	if err := farmRepository.CreateWithPonds(newFarms, newPonds); err != nil {
+		return nil, true, err
+	}
+	return results, true, nil
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type LogHandler struct {
+	logRepository repository.LogRepository
+}
+
+func NewLogHandler(
+	logRepository repository.LogRepository,
+) *LogHandler {
+	return &LogHandler{
+		logRepository: logRepository,
+	}
+}
+
+// LogRequest is a middleware that records every matched request using the
+// route template once the handler completes, so a failing log write never
+// affects the response. The log belongs to the organization the request
+// authenticated in, if any.
+func (h *LogHandler) LogRequest(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	// Unmatched route
+	if c.FullPath() == "" {
+		return
+	}
+
+	// Create log
+	requestLog := model.Log{
+		CreatedAt:     start.UTC(),
+		Method:        c.Request.Method,
+		Route:         c.FullPath(),
+		Endpoint:      c.Request.Method + " " + c.FullPath(),
+		UserAgent:     c.GetHeader("User-Agent"),
+		Subject:       c.GetString(ContextMember),
+		StatusCode:    c.Writer.Status(),
+		LatencyMicros: time.Since(start).Microseconds(),
+		ResponseSize:  c.Writer.Size(),
+		ClientIP:      c.ClientIP(),
+	}
+
+	// Empty body
+	if requestLog.ResponseSize < 0 {
+		requestLog.ResponseSize = 0
+	}
+
+	logRepository := h.logRepository.InOrganization(c.GetInt(ContextOrganization))
+	if err := logRepository.Create(&requestLog); err != nil {
+		log.WithFields(log.Fields{"error": err, "endpoint": requestLog.Endpoint}).Warn("Failed to create log")
+	}
+}
+
+// ExportLog streams the logs of the organization created within the optional
+// from and to query params as NDJSON or CSV
+func (h *LogHandler) ExportLog(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceLog, model.ActionRead) {
+		return
+	}
+
+	// Get query from and to
+	from, to, ok := bindTimeRange(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request query",
+		})
+		return
+	}
+
+	header := []string{"id", "created_at", "method", "route", "endpoint", "user_agent", "subject", "status_code", "latency_us", "response_size", "client_ip"}
+	writer, ok := newExportWriter(c, "logs", header)
+	if !ok {
+		return
+	}
+
+	logRepository := h.logRepository.InOrganization(c.GetInt(ContextOrganization))
+	writer.Close(logRepository.Export(from, to, func(requestLog model.Log) error {
+		return writer.Write(requestLog, []string{
+			strconv.Itoa(requestLog.ID),
+			requestLog.CreatedAt.UTC().Format(time.RFC3339Nano),
+			requestLog.Method,
+			requestLog.Route,
+			requestLog.Endpoint,
+			requestLog.UserAgent,
+			requestLog.Subject,
+			strconv.Itoa(requestLog.StatusCode),
+			strconv.FormatInt(requestLog.LatencyMicros, 10),
+			strconv.Itoa(requestLog.ResponseSize),
+			requestLog.ClientIP,
+		})
+	}))
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MembershipHandler struct {
+	farmRepository       repository.FarmRepository
+	farmMemberRepository repository.FarmMemberRepository
+}
+
+func NewMembershipHandler(
+	farmRepository repository.FarmRepository,
+	farmMemberRepository repository.FarmMemberRepository,
+) *MembershipHandler {
+	return &MembershipHandler{
+		farmRepository:       farmRepository,
+		farmMemberRepository: farmMemberRepository,
+	}
+}
+
+// scopeFarms confines a farm repository to the organization of the request
+// and, unless its role reaches every farm, to the farms its member subject is
+// a member of, auditing its mutations under the actor of the request
+func scopeFarms(c *gin.Context, farmRepository repository.FarmRepository) repository.FarmRepository {
+	scoped := farmRepository.InOrganization(c.GetInt(ContextOrganization))
+	if !model.RoleReachesAllFarms(c.GetString(ContextRole)) {
+		scoped = scoped.ForMember(c.GetString(ContextMember))
+	}
+	return scoped.AuditedBy(actor(c))
+}
+
+// scopePonds confines a pond repository to the ponds of the farms scopeFarms
+// reaches, auditing its mutations under the actor of the request
+func scopePonds(c *gin.Context, pondRepository repository.PondRepository) repository.PondRepository {
+	scoped := pondRepository.InOrganization(c.GetInt(ContextOrganization))
+	if !model.RoleReachesAllFarms(c.GetString(ContextRole)) {
+		scoped = scoped.ForMember(c.GetString(ContextMember))
+	}
+	return scoped.AuditedBy(actor(c))
+}
+
+func (h *MembershipHandler) GetFarmMember(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceMembership, model.ActionRead) {
+		return
+	}
+
+	farm, ok := h.bindFarm(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.farmMemberRepository.GetByFarmId(farm.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to fetch farm members",
+		})
+		return
+	}
+
+	// Success
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Farm members fetched successfully",
+		"data":    members,
+	})
+}
+
+// CreateFarmMember grants a subject access to a farm and its ponds, refusing
+// subjects not in a model.FarmMember format
+func (h *MembershipHandler) CreateFarmMember(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceMembership, model.ActionCreate) {
+		return
+	}
+
+	farm, ok := h.bindFarm(c)
+	if !ok {
+		return
+	}
+
+	var member model.FarmMember
+
+	// Bind payload
+	if err := c.Bind(&member); err != nil || !model.IsMemberSubject(member.Subject) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return
+	}
+	member.FarmID = farm.ID
+	member.CreatedAt = time.Now().UTC()
+
+	// Exist member
+	existMember, _ := h.farmMemberRepository.Get(farm.ID, member.Subject)
+	if existMember != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"status":  "error",
+			"message": "Subject is already a member of the farm",
+		})
+		return
+	}
+
+	// Grant member
+	if err := h.farmMemberRepository.Grant(&member); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to grant farm member",
+		})
+		return
+	}
+
+	// Success
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Farm member granted successfully",
+		"data":    member,
+	})
+}
+
+// DeleteFarmMember revokes the access of a subject to a farm and its ponds
+func (h *MembershipHandler) DeleteFarmMember(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourceMembership, model.ActionDelete) {
+		return
+	}
+
+	farm, ok := h.bindFarm(c)
+	if !ok {
+		return
+	}
+
+	// Revoke member
+	if err := h.farmMemberRepository.Revoke(farm.ID, c.Param("subject")); errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Member Not Found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to revoke farm member",
+		})
+		return
+	}
+
+	// Success
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Farm member revoked successfully",
+	})
+}
+
+// bindFarm reads the farm of the path among the farms the request reaches,
+// responding 400 or 404 otherwise
+func (h *MembershipHandler) bindFarm(c *gin.Context) (*model.Farm, bool) {
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return nil, false
+	}
+
+	// Empty farm
+	farm, _ := scopeFarms(c, h.farmRepository).GetById(id)
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+		return nil, false
+	}
+	return farm, true
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"strings"
+	"time"
+)
+
+// nameConflictMessage tells apart a name taken by a deleted record, which
+// stays reserved until the record is restored
+func nameConflictMessage(resource string, deletedAt *time.Time) string {
+	if deletedAt != nil {
+		return resource + " name already exists in a deleted " + strings.ToLower(resource)
+	}
+	return resource + " name already exists"
+}
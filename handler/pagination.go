@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/gin-gonic/gin"
+)
+
+// bindListQuery reads the page, page_size, sort, name_contains, farm_id and
+// include_deleted query params, returning false when any of them is invalid
+func bindListQuery(c *gin.Context) (model.ListQuery, bool) {
+	query := model.ListQuery{
+		Sort:         c.Query("sort"),
+		NameContains: c.Query("name_contains"),
+	}
+
+	var errPage, errPageSize, errFarmID, errIncludeDeleted error
+	query.Page, errPage = strconv.Atoi(c.DefaultQuery("page", "1"))
+	query.PageSize, errPageSize = strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(model.DefaultPageSize)))
+	query.FarmID, errFarmID = strconv.Atoi(c.DefaultQuery("farm_id", "0"))
+	query.IncludeDeleted, errIncludeDeleted = strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
+
+	return query, errPage == nil && errPageSize == nil && errFarmID == nil && errIncludeDeleted == nil && query.IsValid()
+}
+
+// pagination describes the page of query, linking to the next page when
+// more data remains
+func pagination(c *gin.Context, query model.ListQuery, total int64) model.Pagination {
+	page := model.Pagination{
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Total:    total,
+	}
+
+	if int64(query.Offset()+query.PageSize) < total {
+		next := *c.Request.URL
+		values := next.Query()
+		values.Set("page", strconv.Itoa(query.Page+1))
+		values.Set("page_size", strconv.Itoa(query.PageSize))
+		next.RawQuery = values.Encode()
+		page.Next = next.RequestURI()
+	}
+	return page
+}
+
+// bindTimeRange reads the optional RFC3339 from and to query params, returning
+// false when either is invalid or the range is empty
+func bindTimeRange(c *gin.Context) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	var errFrom, errTo error
+	if c.Query("from") != "" {
+		from, errFrom = time.Parse(time.RFC3339, c.Query("from"))
+	}
+	if c.Query("to") != "" {
+		to, errTo = time.Parse(time.RFC3339, c.Query("to"))
+	}
+	if errFrom != nil || errTo != nil || (!from.IsZero() && !to.IsZero() && !from.Before(to)) {
+		return from, to, false
+	}
+	return from, to, true
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
+	"github.com/gin-gonic/gin"
+)
+
+// bindMergePatch applies the JSON Merge Patch request body to original and
+// binds the result to patched
+func bindMergePatch(c *gin.Context, original interface{}, patched interface{}) error {
+	patch, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+
+	document, err := json.Marshal(original)
+	if err != nil {
+		return err
+	}
+
+	document, err = utility.MergePatch(document, patch)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(document, patched)
+}
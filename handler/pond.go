@@ -1,336 +1,775 @@
 package handler
 
 import (
-    "net/http"
-    "strconv"
+	"errors"
+	"net/http"
+	"strconv"
 
-    "github.com/gin-gonic/gin"
-    "github.com/WillyWilsen/Delos-Task-Assignment.git/model"
-    "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/gin-gonic/gin"
 )
 
 type PondHandler struct {
-    pondRepository repository.PondRepository
-	farmRepository repository.FarmRepository
-	logRepository repository.LogRepository
+	pondRepository  repository.PondRepository
+	farmRepository  repository.FarmRepository
+	auditRepository repository.AuditRepository
+	strictPut       bool
 }
 
 func NewPondHandler(
-	pondRepository repository.PondRepository, 
+	pondRepository repository.PondRepository,
 	farmRepository repository.FarmRepository,
-	logRepository repository.LogRepository,
+	auditRepository repository.AuditRepository,
+	strictPut bool,
 ) *PondHandler {
-    return &PondHandler{
-        pondRepository: pondRepository,
-		farmRepository: farmRepository,
-		logRepository: logRepository,
-    }
+	return &PondHandler{
+		pondRepository:  pondRepository,
+		farmRepository:  farmRepository,
+		auditRepository: auditRepository,
+		strictPut:       strictPut,
+	}
+}
+
+// ponds scopes the pond repository to the ponds the request reaches
+func (h *PondHandler) ponds(c *gin.Context) repository.PondRepository {
+	return scopePonds(c, h.pondRepository)
+}
+
+// farms scopes the farm repository to the farms the request reaches
+func (h *PondHandler) farms(c *gin.Context) repository.FarmRepository {
+	return scopeFarms(c, h.farmRepository)
 }
 
 func (h *PondHandler) CreatePond(c *gin.Context) {
-	// Create log
-    log := model.Log{
-		Endpoint:  "POST /pond",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    var pond model.Pond
-
-    // Bind payload
-    if err := c.Bind(&pond); err != nil || pond.Name == "" {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request payload",
-        })
-        return
-    }
-
-    // Exist pond name
-    existPond, _ := h.pondRepository.GetByName(pond.Name)
-    if existPond != nil {
-        c.JSON(http.StatusConflict, gin.H{
-            "code": http.StatusConflict,
-            "status": "error",
-            "message": "Pond name already exists",
-        })
-        return
-    }
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionCreate) {
+		return
+	}
+
+	var pond model.Pond
+
+	// Bind payload
+	if err := c.Bind(&pond); err != nil || pond.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return
+	}
+
+	h.createPond(c, &pond)
+}
+
+func (h *PondHandler) CreateFarmPond(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionCreate) {
+		return
+	}
+
+	// Get param id
+	farmId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	var pond model.Pond
+
+	// Bind payload
+	if err := c.Bind(&pond); err != nil || pond.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return
+	}
+	pond.FarmID = farmId
+
+	h.createPond(c, &pond)
+}
+
+// createPond checks the name and farm of a bound pond before creating it
+func (h *PondHandler) createPond(c *gin.Context, pond *model.Pond) {
+	pond.DeletedAt = nil
+
+	// Exist pond name
+	existPond, _ := h.ponds(c).GetByName(pond.Name)
+	if existPond != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"status":  "error",
+			"message": nameConflictMessage("Pond", existPond.DeletedAt),
+		})
+		return
+	}
 
 	// Farm data not found
-	farm, _ := h.farmRepository.GetById(pond.FarmID)
-    if farm == nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "code": http.StatusNotFound,
-            "status": "error",
-            "message": "Farm Data Not Found",
-        })
-        return
-    }
-
-    // Create pond
-    if err := h.pondRepository.Create(&pond); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create pond",
-        })
-        return
-    }
+	farm, _ := h.farms(c).GetById(pond.FarmID)
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Farm Data Not Found",
+		})
+		return
+	}
+
+	// Create pond
+	if err := h.ponds(c).Create(pond); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to create pond",
+		})
+		return
+	}
 
 	// Success
-    c.JSON(http.StatusOK, gin.H{
-        "code": http.StatusOK,
-        "status": "success",
-        "message": "New pond created successfully",
-        "data": pond,
-    })
+	c.Header("ETag", etag(pond.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "New pond created successfully",
+		"data":    pond,
+	})
 }
 
 func (h *PondHandler) GetPond(c *gin.Context) {
-	// Create log
-    log := model.Log{
-		Endpoint:  "GET /pond",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    pond, _ := h.pondRepository.Get()
-
-    // Empty pond
-    if pond == nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "code": http.StatusNotFound,
-            "status": "error",
-            "message": "Data Not Found",
-        })
-        return
-    }
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionRead) {
+		return
+	}
+
+	// Get query
+	query, ok := bindListQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request query",
+		})
+		return
+	}
+
+	h.listPond(c, query)
+}
+
+func (h *PondHandler) GetFarmPond(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionRead) {
+		return
+	}
+
+	// Get param id
+	farmId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	// Get query
+	query, ok := bindListQuery(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request query",
+		})
+		return
+	}
+	query.FarmID = farmId
+
+	// Farm data not found
+	farm, _ := h.farms(c).GetById(farmId)
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Farm Data Not Found",
+		})
+		return
+	}
+
+	h.listPond(c, query)
+}
+
+func (h *PondHandler) listPond(c *gin.Context, query model.ListQuery) {
+	pond, total, err := h.ponds(c).List(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to fetch pond",
+		})
+		return
+	}
 
 	// Success
-    c.JSON(http.StatusOK, gin.H{
-        "code": http.StatusOK,
-        "status": "success",
-        "message": "Pond fetched successfully",
-        "data": pond,
-    })
+	c.JSON(http.StatusOK, gin.H{
+		"code":       http.StatusOK,
+		"status":     "success",
+		"message":    "Pond fetched successfully",
+		"data":       pond,
+		"pagination": pagination(c, query, total),
+	})
+}
+
+// ExportPond streams every pond along with its farm name as NDJSON or CSV
+func (h *PondHandler) ExportPond(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionRead) {
+		return
+	}
+
+	writer, ok := newExportWriter(c, "ponds", []string{"id", "name", "farm_id", "farm_name"})
+	if !ok {
+		return
+	}
+
+	writer.Close(h.ponds(c).Export(func(pond model.PondExport) error {
+		return writer.Write(pond, []string{strconv.Itoa(pond.ID), pond.Name, strconv.Itoa(pond.FarmID), pond.FarmName})
+	}))
 }
 
 func (h *PondHandler) GetPondById(c *gin.Context) {
-	// Create log
-    log := model.Log{
-		Endpoint:  "GET /pond/:id",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    // Get param id
-    id, err := strconv.Atoi(c.Param("id"))
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request param",
-        })
-        return
-    }
-
-    pond, _ := h.pondRepository.GetById(id)
-
-    // Empty pond
-    if pond == nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "code": http.StatusNotFound,
-            "status": "error",
-            "message": "Data Not Found",
-        })
-        return
-    }
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionRead) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	pond, _ := h.ponds(c).GetById(id)
+
+	// Empty pond
+	if pond == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+		return
+	}
+
+	// Unchanged pond
+	if !checkIfNoneMatch(c, pond.Version) {
+		return
+	}
 
 	// Success
-    c.JSON(http.StatusOK, gin.H{
-        "code": http.StatusOK,
-        "status": "success",
-        "message": "Pond fetched successfully",
-        "data": pond,
-    })
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Pond fetched successfully",
+		"data":    pond,
+	})
 }
 
 func (h *PondHandler) UpdatePond(c *gin.Context) {
-	// Create log
-    log := model.Log{
-		Endpoint:  "PUT /pond/:id",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    // Get param id
-    id, err := strconv.Atoi(c.Param("id"))
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request param",
-        })
-        return
-    }
-
-    // Bind payload
-    var pondPayload model.Pond
-    if err := c.Bind(&pondPayload); err != nil || pondPayload.Name == "" {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request payload",
-        })
-        return
-    }
-
-    // Exist pond name
-    existPond, _ := h.pondRepository.GetByName(pondPayload.Name)
-    if existPond != nil && existPond.ID != id {
-        c.JSON(http.StatusConflict, gin.H{
-            "code": http.StatusConflict,
-            "status": "error",
-            "message": "Pond name already exists",
-        })
-        return
-    }
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionUpdate) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	// Bind payload
+	var pondPayload model.Pond
+	if err := c.Bind(&pondPayload); err != nil || pondPayload.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return
+	}
+	pondPayload.DeletedAt = nil
+
+	// Exist pond name
+	existPond, _ := h.ponds(c).GetByName(pondPayload.Name)
+	if existPond != nil && (existPond.ID != id || existPond.DeletedAt != nil) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"status":  "error",
+			"message": nameConflictMessage("Pond", existPond.DeletedAt),
+		})
+		return
+	}
+
+	// Farm data not found
+	farm, _ := h.farms(c).GetById(pondPayload.FarmID)
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Farm Data Not Found",
+		})
+		return
+	}
+
+	pond, _ := h.ponds(c).GetById(id)
+
+	// Empty pond
+	if pond == nil {
+		// If-Match precondition
+		if !checkIfMatch(c, 0, false) {
+			return
+		}
+
+		// Deleted pond
+		deletedPond, _ := h.ponds(c).GetDeletedById(id)
+		if deletedPond != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"code":    http.StatusConflict,
+				"status":  "error",
+				"message": "Pond is deleted, restore it instead",
+			})
+			return
+		}
+
+		// Strict PUT
+		if h.strictPut {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"status":  "error",
+				"message": "Data Not Found",
+			})
+			return
+		}
+
+		// Role policy
+		if !authorize(c, model.ResourcePond, model.ActionCreate) {
+			return
+		}
+
+		// Create pond at the requested id
+		pondPayload.ID = id
+		if err := h.ponds(c).Create(&pondPayload); errors.Is(err, repository.ErrIdTaken) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"status":  "error",
+				"message": "Data Not Found",
+			})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"status":  "error",
+				"message": "Failed to create pond",
+			})
+			return
+		}
+
+		// Success
+		c.Header("Location", c.Request.URL.Path)
+		c.Header("ETag", etag(pondPayload.Version))
+		c.JSON(http.StatusCreated, gin.H{
+			"code":    http.StatusCreated,
+			"status":  "success",
+			"message": "Data Not Found. New pond created successfully",
+			"data":    pondPayload,
+		})
+	} else {
+		// If-Match precondition
+		if !checkIfMatch(c, pond.Version, true) {
+			return
+		}
+
+		// Update pond
+		pondPayload.Version = pond.Version
+		if err := h.ponds(c).Update(pond.ID, &pondPayload); errors.Is(err, repository.ErrVersionConflict) {
+			respondPreconditionFailed(c)
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"status":  "error",
+				"message": "Failed to create pond",
+			})
+			return
+		}
+		pondPayload.ID = pond.ID
+
+		// Success
+		c.Header("ETag", etag(pondPayload.Version))
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"status":  "success",
+			"message": "Pond updated successfully",
+			"data":    pondPayload,
+		})
+	}
+}
+
+func (h *PondHandler) PatchPond(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionUpdate) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	pond, _ := h.ponds(c).GetById(id)
+
+	// Empty pond
+	if pond == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+		return
+	}
+
+	// If-Match precondition
+	if !checkIfMatch(c, pond.Version, true) {
+		return
+	}
+
+	// Bind merge patch payload
+	var pondPayload model.Pond
+	if err := bindMergePatch(c, pond, &pondPayload); err != nil || pondPayload.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request payload",
+		})
+		return
+	}
+	pondPayload.ID = pond.ID
+	pondPayload.DeletedAt = nil
+	pondPayload.Version = pond.Version
+
+	// Exist pond name
+	existPond, _ := h.ponds(c).GetByName(pondPayload.Name)
+	if existPond != nil && (existPond.ID != id || existPond.DeletedAt != nil) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    http.StatusConflict,
+			"status":  "error",
+			"message": nameConflictMessage("Pond", existPond.DeletedAt),
+		})
+		return
+	}
 
 	// Farm data not found
-	farm, _ := h.farmRepository.GetById(pondPayload.FarmID)
-    if farm == nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "code": http.StatusNotFound,
-            "status": "error",
-            "message": "Farm Data Not Found",
-        })
-        return
-    }
-
-    pond, _ := h.pondRepository.GetById(id)
-
-    // Empty pond
-    if pond == nil {
-        // Create pond
-        if err := h.pondRepository.Create(&pondPayload); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{
-                "code": http.StatusInternalServerError,
-                "status": "error",
-                "message": "Failed to create pond",
-            })
-            return
-        }
-
-        // Success
-        c.JSON(http.StatusOK, gin.H{
-            "code": http.StatusOK,
-            "status": "success",
-            "message": "Data Not Found. New pond created successfully",
-            "data": pondPayload,
-        })
-    } else {
-        // Update pond
-        if err := h.pondRepository.Update(pond.ID, &pondPayload); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{
-                "code": http.StatusInternalServerError,
-                "status": "error",
-                "message": "Failed to create pond",
-            })
-            return
-        }
-        pondPayload.ID = pond.ID
-
-        // Success
-        c.JSON(http.StatusOK, gin.H{
-            "code": http.StatusOK,
-            "status": "success",
-            "message": "Pond updated successfully",
-            "data": pondPayload,
-        })
-    }
+	farm, _ := h.farms(c).GetById(pondPayload.FarmID)
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Farm Data Not Found",
+		})
+		return
+	}
+
+	// Update pond
+	if err := h.ponds(c).Update(pond.ID, &pondPayload); errors.Is(err, repository.ErrVersionConflict) {
+		respondPreconditionFailed(c)
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to update pond",
+		})
+		return
+	}
+
+	// Success
+	c.Header("ETag", etag(pondPayload.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Pond updated successfully",
+		"data":    pondPayload,
+	})
 }
 
 func (h *PondHandler) DeletePond(c *gin.Context) {
-	// Create log
-    log := model.Log{
-		Endpoint:  "DELETE /pond/:id",
-		UserAgent: c.GetHeader("User-Agent"),
-	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    // Get param id
-    id, err := strconv.Atoi(c.Param("id"))
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "code": http.StatusBadRequest,
-            "status": "error",
-            "message": "Invalid request param",
-        })
-        return
-    }
-
-    pond, _ := h.pondRepository.GetById(id)
-
-    // Empty pond
-    if pond == nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "code": http.StatusNotFound,
-            "status": "error",
-            "message": "Data Not Found",
-        })
-    } else {
-        // Delete pond
-        if err := h.pondRepository.Delete(pond); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{
-                "code": http.StatusInternalServerError,
-                "status": "error",
-                "message": "Failed to delete pond",
-            })
-            return
-        }
-
-        // Success
-        c.JSON(http.StatusOK, gin.H{
-            "code": http.StatusOK,
-            "status": "success",
-            "message": "Pond deleted successfully",
-        })
-    }
-}
\ No newline at end of file
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionDelete) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	pond, _ := h.ponds(c).GetById(id)
+
+	// Empty pond
+	if pond == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+	} else if checkIfMatch(c, pond.Version, true) {
+		// Delete pond
+		if err := h.ponds(c).Delete(pond); errors.Is(err, repository.ErrVersionConflict) {
+			respondPreconditionFailed(c)
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"status":  "error",
+				"message": "Failed to delete pond",
+			})
+			return
+		}
+
+		// Success
+		c.JSON(http.StatusOK, gin.H{
+			"code":    http.StatusOK,
+			"status":  "success",
+			"message": "Pond deleted successfully",
+		})
+	}
+}
+
+func (h *PondHandler) RestorePond(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionRestore) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	pond, _ := h.ponds(c).GetDeletedById(id)
+
+	// Empty deleted pond
+	if pond == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Deleted Data Not Found",
+		})
+		return
+	}
+
+	// Farm data not found
+	farm, _ := h.farms(c).GetById(pond.FarmID)
+	if farm == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Farm Data Not Found",
+		})
+		return
+	}
+
+	// If-Match precondition
+	if !checkIfMatch(c, pond.Version, true) {
+		return
+	}
+
+	// Restore pond
+	if err := h.ponds(c).Restore(pond); errors.Is(err, repository.ErrVersionConflict) {
+		respondPreconditionFailed(c)
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to restore pond",
+		})
+		return
+	}
+
+	// Success
+	c.Header("ETag", etag(pond.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Pond restored successfully",
+		"data":    pond,
+	})
+}
+
+func (h *PondHandler) GetPondHistory(c *gin.Context) {
+	// Role policy
+	if !authorize(c, model.ResourcePond, model.ActionRead) {
+		return
+	}
+
+	// Get param id
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request param",
+		})
+		return
+	}
+
+	// Pond of another organization
+	pond, _ := h.ponds(c).GetById(id)
+	if pond == nil {
+		pond, _ = h.ponds(c).GetDeletedById(id)
+	}
+	if pond == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    http.StatusNotFound,
+			"status":  "error",
+			"message": "Data Not Found",
+		})
+		return
+	}
+
+	respondHistory(h.auditRepository, c, model.AuditEntityPond, id)
+}
+
+// BulkPond validates every item against the batch, the stored ponds and their
+// farms up front, then applies the valid ones in a single transaction
+func (h *PondHandler) BulkPond(c *gin.Context) {
+	items, atomic, ok := bindBulk(c, model.ResourcePond, func(item model.PondBulkItem) model.BulkOperation {
+		return item.Operation
+	})
+	if !ok {
+		return
+	}
+
+	// Referenced ponds and farms
+	names := make([]string, 0, len(items))
+	ids := make([]int, 0, len(items))
+	farmIds := make([]int, 0, len(items))
+	operations := make([]model.BulkOperation, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+		ids = append(ids, item.ID)
+		farmIds = append(farmIds, item.FarmID)
+		operations = append(operations, item.Operation)
+	}
+	existPonds, errNames := h.ponds(c).GetByNames(names)
+	ponds, errIds := h.ponds(c).GetByIds(ids)
+	farms, errFarms := h.farms(c).GetByIds(farmIds)
+	if errNames != nil || errIds != nil || errFarms != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to apply bulk pond",
+		})
+		return
+	}
+	pondsByName := make(map[string]model.Pond, len(existPonds))
+	for _, pond := range existPonds {
+		pondsByName[pond.Name] = pond
+	}
+	pondsById := make(map[int]model.Pond, len(ponds))
+	for _, pond := range ponds {
+		pondsById[pond.ID] = pond
+	}
+	farmExists := make(map[int]bool, len(farms))
+	for _, farm := range farms {
+		farmExists[farm.ID] = true
+	}
+
+	// Validate items
+	batch := newBulkBatch(model.ResourcePond, atomic, operations)
+	for i := range items {
+		item := &items[i]
+		item.DeletedAt = nil
+		if !batch.checkPayload(i, item.Name) {
+			continue
+		}
+
+		if item.Operation == model.BulkCreate {
+			item.ID = 0
+		} else {
+			pond, ok := pondsById[item.ID]
+			if !batch.checkTarget(i, item.ID, ok) {
+				continue
+			}
+			item.Version = pond.Version
+
+			if item.Operation == model.BulkDelete {
+				item.Pond = pond
+				continue
+			}
+		}
+
+		// Exist pond name
+		existPond, ok := pondsByName[item.Name]
+		if !batch.checkName(i, item.ID, item.Name, existPond.ID, existPond.DeletedAt, ok) {
+			continue
+		}
+
+		// Farm data not found
+		if !farmExists[item.FarmID] {
+			batch.fail(i, http.StatusNotFound, "Farm Data Not Found")
+		}
+	}
+	validIndexes, ok := batch.valid(c)
+	if !ok {
+		return
+	}
+
+	// Apply ponds
+	validItems := pickBulk(items, validIndexes)
+	errs, err := h.ponds(c).Bulk(validItems, atomic)
+	for j, i := range validIndexes {
+		item := validItems[j]
+		if !batch.applied(i, errs[j], err) {
+			continue
+		}
+		if item.Operation != model.BulkDelete {
+			batch.results[i].Data = item.Pond
+		}
+	}
+	batch.respond(c, err)
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/gin-gonic/gin"
+)
+
+// authorize responds 403 unless the role of the request may perform every
+// action on resource
+func authorize(c *gin.Context, resource string, actions ...string) bool {
+	role := c.GetString(ContextRole)
+	for _, action := range actions {
+		if model.RoleAllows(role, resource, action) {
+			continue
+		}
+
+		// Missing or forbidden role
+		message := "Role " + role + " is not allowed to " + action + " " + resource
+		if role == "" {
+			message = "Missing role"
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    http.StatusForbidden,
+			"status":  "error",
+			"message": message,
+		})
+		return false
+	}
+	return true
+}
+
+// bulkActions lists the actions of the bulk operations, which are named after
+// them. As an unknown operation cannot be authorized, it responds 400 with
+// the items holding one instead, before the role policy is checked.
+func bulkActions(c *gin.Context, resource string, operations []model.BulkOperation) ([]string, bool) {
+	actions := make([]string, 0, len(operations))
+	results := make([]model.BulkResult, len(operations))
+	valid := true
+	for i, operation := range operations {
+		results[i] = model.BulkResult{Index: i, Operation: operation}
+		if !operation.IsValid() {
+			bulkError(&results[i], http.StatusBadRequest, "Invalid request payload")
+			valid = false
+			continue
+		}
+		actions = append(actions, string(operation))
+	}
+
+	if !valid {
+		skipBulk(results)
+		respondBulk(c, resource, true, http.StatusBadRequest, results)
+		return nil, false
+	}
+	return actions, true
+}
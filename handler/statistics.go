@@ -1,55 +1,86 @@
 package handler
 
 import (
-    "net/http"
+	"net/http"
 
-    "github.com/gin-gonic/gin"
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
-    "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/gin-gonic/gin"
 )
 
 type StatisticsHandler struct {
-    logRepository repository.LogRepository
+	logRepository repository.LogRepository
 }
 
 func NewStatisticsHandler(
-    logRepository repository.LogRepository,
+	logRepository repository.LogRepository,
 ) *StatisticsHandler {
-    return &StatisticsHandler{
-        logRepository: logRepository,
-    }
+	return &StatisticsHandler{
+		logRepository: logRepository,
+	}
 }
 
 func (h *StatisticsHandler) GetStatistics(c *gin.Context) {
-	// Create log
-    log := model.Log{
-		Endpoint:  "GET /log",
-		UserAgent: c.GetHeader("User-Agent"),
+	// Role policy
+	if !authorize(c, model.ResourceStatistics, model.ActionRead) {
+		return
+	}
+
+	// Time-windowed statistics
+	if c.Query("from") != "" || c.Query("to") != "" || c.Query("bucket") != "" {
+		h.getBucketStatistics(c)
+		return
+	}
+
+	logRepository := h.logRepository.InOrganization(c.GetInt(ContextOrganization))
+	statistics, err := logRepository.GetStatistics()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to fetch statistic",
+		})
+		return
+	}
+
+	// Success
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Statistic fetched successfully",
+		"data":    statistics,
+	})
+}
+
+func (h *StatisticsHandler) getBucketStatistics(c *gin.Context) {
+	// Get query from, to and bucket
+	from, to, ok := bindTimeRange(c)
+	bucket := model.StatisticsBucket(c.DefaultQuery("bucket", string(model.BucketDay)))
+	if !ok || !bucket.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"status":  "error",
+			"message": "Invalid request query",
+		})
+		return
 	}
-    if err := h.logRepository.Create(&log); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "code": http.StatusInternalServerError,
-            "status": "error",
-            "message": "Failed to create log",
-        })
-        return
-    }
-
-    // Get Endpoints List
-    endpoints, _ := h.logRepository.GetDistinctEndpoints()
-
-    // Make statistics
-    statistics := make(model.Statistics)
-	for _, endpoint := range endpoints {
-		endpointStatistics, _ := h.logRepository.GetEndpointStatistics(endpoint)
-		statistics[endpoint] = endpointStatistics
+
+	logRepository := h.logRepository.InOrganization(c.GetInt(ContextOrganization))
+	statistics, err := logRepository.GetBucketStatistics(from, to, bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"status":  "error",
+			"message": "Failed to fetch statistic",
+		})
+		return
 	}
 
 	// Success
-    c.JSON(http.StatusOK, gin.H{
-        "code": http.StatusOK,
-        "status": "success",
-        "message": "Statistic fetched successfully",
-        "data": statistics,
-    })
-}
\ No newline at end of file
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"status":  "success",
+		"message": "Statistic fetched successfully",
+		"data":    statistics,
+	})
+}
@@ -2,14 +2,17 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/database"
-	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
-	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
 )
 
 func main() {
@@ -27,44 +30,134 @@ func main() {
 	}
 	defer db.Close()
 
+	// Migration command
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if errCommand := migrateCommand(os.Args[2:], gormDB); errCommand != nil {
+			log.WithFields(log.Fields{"error": errCommand}).Fatal("Failed to run command")
+		}
+		return
+	}
+
+	// Schema
+	if errMigration := prepareSchema(gormDB, configuration); errMigration != nil {
+		log.WithFields(log.Fields{"error": errMigration}).Fatal("Database schema is not up to date")
+	}
+
+	jwtVerifier, errJwt := utility.NewJwtVerifier(configuration)
+	if errJwt != nil {
+		log.WithFields(log.Fields{"error": errJwt}).Fatal("Failed to load JWT keys")
+	}
+
+	// Command
+	if len(os.Args) > 1 {
+		apiKeyRepository := repository.NewApiKeyRepository(gormDB)
+		organizationRepository := repository.NewOrganizationRepository(gormDB)
+		importHandler := handler.NewImportHandler(repository.NewFarmRepository(gormDB), repository.NewPondRepository(gormDB))
+
+		var errCommand error
+		switch os.Args[1] {
+		case "import":
+			errCommand = importCommand(os.Args[2:], importHandler, organizationRepository)
+		case "apikey":
+			errCommand = apiKeyCommand(os.Args[2:], apiKeyRepository, organizationRepository)
+		case "organization":
+			errCommand = organizationCommand(os.Args[2:], organizationRepository)
+		default:
+			errCommand = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if errCommand != nil {
+			log.WithFields(log.Fields{"error": errCommand}).Fatal("Failed to run command")
+		}
+		return
+	}
+
+	router := newRouter(configuration, gormDB, jwtVerifier)
+	errServer := router.Run(":" + configuration.Http.HttpPort)
+	if errServer != nil {
+		utility.PrintConsole(fmt.Sprintf("%v", errServer.Error()), "error")
+	}
+}
+
+// prepareSchema checks every migration is applied, applying them first to an
+// in-memory SQLite database, which starts empty
+func prepareSchema(gormDB *gorm.DB, configuration utility.Configuration) error {
+	if database.InMemory(configuration) {
+		_, err := database.MigrateUp(gormDB)
+		return err
+	}
+	return database.CheckMigrations(gormDB)
+}
+
+// newRouter wires the repositories and handlers of the API on gormDB
+func newRouter(configuration utility.Configuration, gormDB *gorm.DB, jwtVerifier *utility.JwtVerifier) *gin.Engine {
 	// Repository
 	farmRepository := repository.NewFarmRepository(gormDB)
 	pondRepository := repository.NewPondRepository(gormDB)
 	logRepository := repository.NewLogRepository(gormDB)
+	auditRepository := repository.NewAuditRepository(gormDB)
+	apiKeyRepository := repository.NewApiKeyRepository(gormDB)
+	organizationRepository := repository.NewOrganizationRepository(gormDB)
+	farmMemberRepository := repository.NewFarmMemberRepository(gormDB)
 
 	// Handler
-	farmHandler := handler.NewFarmHandler(farmRepository, logRepository)
-	pondHandler := handler.NewPondHandler(pondRepository, farmRepository, logRepository)
+	farmHandler := handler.NewFarmHandler(farmRepository, pondRepository, auditRepository, configuration.Api.StrictPut)
+	pondHandler := handler.NewPondHandler(pondRepository, farmRepository, auditRepository, configuration.Api.StrictPut)
+	logHandler := handler.NewLogHandler(logRepository)
 	statisticsHandler := handler.NewStatisticsHandler(logRepository)
+	importHandler := handler.NewImportHandler(farmRepository, pondRepository)
+	membershipHandler := handler.NewMembershipHandler(farmRepository, farmMemberRepository)
+	authHandler := handler.NewAuthHandler(apiKeyRepository, organizationRepository, jwtVerifier)
 
 	// Router
 	router := gin.New()
+	// Match path params on the escaped path, so a farm member subject may
+	// hold an escaped slash
+	router.UseRawPath = true
 	router.Use(gin.Logger())
 	router.Use(utility.CORSMiddleware())
+	router.Use(logHandler.LogRequest)
 	router.Use(gin.Recovery())
 	router.NoRoute(func(c *gin.Context) {
 		c.JSON(503, gin.H{"status": "error", "message": "Endpoint not found!"})
 	})
 
-	farmRouter := router.Group("/api/farm")
+	farmRouter := router.Group("/api/farm", authHandler.RequireScope(model.ScopeGroupFarm))
 	farmRouter.POST("/", farmHandler.CreateFarm)
+	farmRouter.POST("/bulk", farmHandler.BulkFarm)
 	farmRouter.GET("/", farmHandler.GetFarm)
+	farmRouter.GET("/export", farmHandler.ExportFarm)
 	farmRouter.GET("/:id", farmHandler.GetFarmById)
 	farmRouter.PUT("/:id", farmHandler.UpdateFarm)
+	farmRouter.PATCH("/:id", farmHandler.PatchFarm)
 	farmRouter.DELETE("/:id", farmHandler.DeleteFarm)
+	farmRouter.POST("/:id/restore", farmHandler.RestoreFarm)
+	farmRouter.GET("/:id/history", farmHandler.GetFarmHistory)
+	farmRouter.GET("/:id/ponds", pondHandler.GetFarmPond)
+	farmRouter.POST("/:id/ponds", pondHandler.CreateFarmPond)
+	farmRouter.GET("/:id/members", membershipHandler.GetFarmMember)
+	farmRouter.POST("/:id/members", membershipHandler.CreateFarmMember)
+	farmRouter.DELETE("/:id/members/:subject", membershipHandler.DeleteFarmMember)
 
-	pondRouter := router.Group("/api/pond")
+	pondRouter := router.Group("/api/pond", authHandler.RequireScope(model.ScopeGroupPond))
 	pondRouter.POST("/", pondHandler.CreatePond)
+	pondRouter.POST("/bulk", pondHandler.BulkPond)
 	pondRouter.GET("/", pondHandler.GetPond)
+	pondRouter.GET("/export", pondHandler.ExportPond)
 	pondRouter.GET("/:id", pondHandler.GetPondById)
 	pondRouter.PUT("/:id", pondHandler.UpdatePond)
+	pondRouter.PATCH("/:id", pondHandler.PatchPond)
 	pondRouter.DELETE("/:id", pondHandler.DeletePond)
+	pondRouter.POST("/:id/restore", pondHandler.RestorePond)
+	pondRouter.GET("/:id/history", pondHandler.GetPondHistory)
 
-	statisticsRouter := router.Group("/api/statistics")
+	logRouter := router.Group("/api/log", authHandler.RequireScope(model.ScopeGroupLog))
+	logRouter.GET("/export", logHandler.ExportLog)
+
+	importRouter := router.Group("/api/import", authHandler.RequireScope(model.ScopeGroupImport))
+	importRouter.POST("/", importHandler.ImportFarmPond)
+
+	statisticsRouter := router.Group("/api/statistics", authHandler.RequireScope(model.ScopeGroupStatistics))
 	statisticsRouter.GET("/", statisticsHandler.GetStatistics)
 
-	errServer := router.Run(":" + configuration.Http.HttpPort)
-	if errServer != nil {
-		utility.PrintConsole(fmt.Sprintf("%v", errServer.Error()), "error")
-	}
-}
\ No newline at end of file
+	return router
+}
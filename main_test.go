@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/database"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
+)
+
+func TestRouter_InMemorySQLite(t *testing.T) {
+	// Open the default SQLite database, held in memory
+	var configuration utility.Configuration
+	configuration.Database.Driver = database.DriverSQLite
+	db, gormDB, err := database.Open(configuration)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Check the schema is applied at startup
+	assert.ErrorIs(t, database.CheckMigrations(gormDB), database.ErrMigrationsPending)
+	require.NoError(t, prepareSchema(gormDB, configuration))
+	assert.NoError(t, database.CheckMigrations(gormDB))
+
+	key, err := utility.GenerateApiKey()
+	require.NoError(t, err)
+	require.NoError(t, repository.NewApiKeyRepository(gormDB).Create(&model.ApiKey{
+		Name:           "Admin",
+		Prefix:         key[:utility.ApiKeyPrefixLength],
+		KeyHash:        utility.HashApiKey(key),
+		Scopes:         "farm:write",
+		Role:           model.RoleAdmin,
+		OrganizationID: model.DefaultOrganizationID,
+		CreatedAt:      time.Now().UTC(),
+	}))
+
+	router := newRouter(configuration, gormDB, &utility.JwtVerifier{})
+
+	// Check a farm can be created and listed back
+	req, _ := http.NewRequest(http.MethodPost, "/api/farm/", strings.NewReader(`{"name": "Farm 1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", key)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	req, _ = http.NewRequest(http.MethodGet, "/api/farm/", nil)
+	req.Header.Set("X-API-Key", key)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"Farm 1"`)
+}
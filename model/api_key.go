@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Route groups guarded by API key and token scopes
+const (
+	ScopeGroupFarm       = "farm"
+	ScopeGroupPond       = "pond"
+	ScopeGroupStatistics = "statistics"
+	ScopeGroupLog        = "log"
+	ScopeGroupImport     = "import"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+var scopeGroups = map[string]bool{
+	ScopeGroupFarm:       true,
+	ScopeGroupPond:       true,
+	ScopeGroupStatistics: true,
+	ScopeGroupLog:        true,
+	ScopeGroupImport:     true,
+}
+
+// ApiKey is stored with the SHA-256 hash of the key only, Prefix being enough
+// to tell keys apart. Scopes is a comma separated list of "group:read" or
+// "group:write" scopes, where write also grants read, and Role picks the
+// actions allowed within those groups. The key only reaches the farms and
+// ponds of its organization.
+type ApiKey struct {
+	ID             int        `json:"id"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	KeyHash        string     `json:"-"`
+	Scopes         string     `json:"scopes"`
+	Role           string     `json:"role"`
+	OrganizationID int        `json:"organization_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Scope names the access to a route group, e.g. "farm:write"
+func Scope(group string, access string) string {
+	return group + ":" + access
+}
+
+// ParseScopes validates a comma separated list of scopes, returning them
+// without blanks or duplicates
+func ParseScopes(scopes string) (string, error) {
+	parsed := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, scope := range strings.Split(scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		group, access, _ := strings.Cut(scope, ":")
+		if !scopeGroups[group] || (access != ScopeRead && access != ScopeWrite) {
+			return "", fmt.Errorf("invalid scope %q, expected group:read or group:write", scope)
+		}
+		parsed = append(parsed, scope)
+		seen[scope] = true
+	}
+	if len(parsed) == 0 {
+		return "", fmt.Errorf("at least one scope is required")
+	}
+	return strings.Join(parsed, ","), nil
+}
+
+// ScopesAllow reports whether scopes grant access to group, write access when
+// write is set
+func ScopesAllow(scopes []string, group string, write bool) bool {
+	for _, scope := range scopes {
+		if scope == Scope(group, ScopeWrite) || (!write && scope == Scope(group, ScopeRead)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityFarm = "farm"
+	AuditEntityPond = "pond"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditUpsert  = "upsert"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+// Audit is a snapshot of an entity before and after a mutation. Before is
+// null for creations.
+type Audit struct {
+	ID        int             `json:"id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Operation string          `json:"operation"`
+	Actor     string          `json:"actor"`
+	Before    json.RawMessage `gorm:"column:before_data" json:"before"`
+	After     json.RawMessage `gorm:"column:after_data" json:"after"`
+}
@@ -0,0 +1,45 @@
+package model
+
+// MaxBulkSize caps the number of items of a bulk request
+const MaxBulkSize = 100
+
+type BulkOperation string
+
+const (
+	BulkCreate BulkOperation = "create"
+	BulkUpdate BulkOperation = "update"
+	BulkDelete BulkOperation = "delete"
+)
+
+func (o BulkOperation) IsValid() bool {
+	return o == BulkCreate || o == BulkUpdate || o == BulkDelete
+}
+
+type BulkStatus string
+
+const (
+	BulkSuccess BulkStatus = "success"
+	BulkError   BulkStatus = "error"
+	// BulkSkipped items were valid but not applied, as the all-or-nothing
+	// request failed on another item
+	BulkSkipped BulkStatus = "skipped"
+)
+
+type FarmBulkItem struct {
+	Operation BulkOperation `json:"operation"`
+	Farm
+}
+
+type PondBulkItem struct {
+	Operation BulkOperation `json:"operation"`
+	Pond
+}
+
+type BulkResult struct {
+	Index     int           `json:"index"`
+	Operation BulkOperation `json:"operation"`
+	Status    BulkStatus    `json:"status"`
+	Code      int           `json:"code"`
+	Message   string        `json:"message,omitempty"`
+	Data      interface{}   `json:"data,omitempty"`
+}
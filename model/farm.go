@@ -1,6 +1,11 @@
 package model
 
+import "time"
+
 type Farm struct {
-    ID      int     `json:"id,omitempty"`
-    Name    string  `json:"name,omitempty"`
-}
\ No newline at end of file
+	ID             int        `json:"id,omitempty"`
+	Name           string     `json:"name,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	Version        int        `json:"-"`
+	OrganizationID int        `json:"-"`
+}
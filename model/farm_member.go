@@ -0,0 +1,48 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiKeyMemberPrefix starts the member subject of an API key, followed by its
+// id, so keys sharing a name or revoked and reissued under it never inherit
+// the farms of one another
+const apiKeyMemberPrefix = "apikey:"
+
+// tokenMemberSeparator joins the issuer and the subject of a bearer token, so
+// subjects of different issuers never collide
+const tokenMemberSeparator = "|"
+
+// FarmMember grants a subject access to a farm and its ponds, either
+// "apikey:<id>" for an API key or "<iss>|<sub>" for the bearer tokens of an
+// issuer. Only admins reach farms without being members.
+type FarmMember struct {
+	FarmID    int       `json:"farm_id"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ApiKeyMember returns the member subject of the API key with id
+func ApiKeyMember(id int) string {
+	return apiKeyMemberPrefix + strconv.Itoa(id)
+}
+
+// TokenMember returns the member subject of the bearer tokens of issuer with
+// subject
+func TokenMember(issuer string, subject string) string {
+	return issuer + tokenMemberSeparator + subject
+}
+
+// IsMemberSubject reports whether subject is in the format of ApiKeyMember or
+// TokenMember
+func IsMemberSubject(subject string) bool {
+	if strings.HasPrefix(subject, apiKeyMemberPrefix) {
+		id := strings.TrimPrefix(subject, apiKeyMemberPrefix)
+		parsedId, err := strconv.Atoi(id)
+		return err == nil && parsedId > 0 && strconv.Itoa(parsedId) == id
+	}
+	issuer, tokenSubject, ok := strings.Cut(subject, tokenMemberSeparator)
+	return ok && issuer != "" && tokenSubject != ""
+}
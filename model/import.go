@@ -0,0 +1,30 @@
+package model
+
+// MaxImportRows caps the number of rows of an import file
+const MaxImportRows = 5000
+
+// MaxImportSize caps the size in bytes of an import request body
+const MaxImportSize = 10 << 20
+
+// ImportRow is a spreadsheet row of a farm and an optional pond. Row is the
+// 1-based spreadsheet row number, counting the header.
+type ImportRow struct {
+	Row  int
+	Farm string
+	Pond string
+}
+
+type ImportResult struct {
+	Row     int        `json:"row"`
+	Farm    string     `json:"farm"`
+	Pond    string     `json:"pond,omitempty"`
+	Status  BulkStatus `json:"status"`
+	Message string     `json:"message,omitempty"`
+}
+
+// ImportPond is an imported pond along with its farm, which may itself be
+// created by the same import
+type ImportPond struct {
+	Pond *Pond
+	Farm *Farm
+}
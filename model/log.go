@@ -1,6 +1,18 @@
 package model
 
+import "time"
+
 type Log struct {
-    Endpoint    string  `json:"name,omitempty"`
-	UserAgent	string	`json:"user_agent,omitempty"`
-}
\ No newline at end of file
+	ID             int       `json:"id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Method         string    `json:"method,omitempty"`
+	Route          string    `json:"route,omitempty"`
+	Endpoint       string    `json:"endpoint,omitempty"`
+	UserAgent      string    `json:"user_agent,omitempty"`
+	Subject        string    `json:"subject,omitempty"`
+	StatusCode     int       `json:"status_code,omitempty"`
+	LatencyMicros  int64     `gorm:"column:latency_us" json:"latency_us"`
+	ResponseSize   int       `json:"response_size"`
+	ClientIP       string    `gorm:"column:client_ip" json:"client_ip,omitempty"`
+	OrganizationID *int      `json:"-"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// DefaultOrganizationID is the organization owning the farms, ponds and API
+// keys created before organizations were introduced
+const DefaultOrganizationID = 1
+
+// Organization is a tenant owning farms, and through them ponds. Names of
+// farms and ponds are unique within an organization only.
+type Organization struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
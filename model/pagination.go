@@ -0,0 +1,48 @@
+package model
+
+import "strings"
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ListQuery holds the pagination, sorting and filtering of a list request.
+// Sort is a column name, prefixed with "-" for descending order.
+type ListQuery struct {
+	Page           int
+	PageSize       int
+	Sort           string
+	NameContains   string
+	FarmID         int
+	IncludeDeleted bool
+}
+
+var sortColumns = map[string]bool{
+	"id":   true,
+	"name": true,
+}
+
+// SortColumn splits Sort into its column and direction, defaulting to id
+func (q ListQuery) SortColumn() (column string, desc bool) {
+	if q.Sort == "" {
+		return "id", false
+	}
+	return strings.TrimPrefix(q.Sort, "-"), strings.HasPrefix(q.Sort, "-")
+}
+
+func (q ListQuery) IsValid() bool {
+	column, _ := q.SortColumn()
+	return q.Page >= 1 && q.PageSize >= 1 && q.PageSize <= MaxPageSize && sortColumns[column] && q.FarmID >= 0
+}
+
+func (q ListQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
+
+type Pagination struct {
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	Total    int64  `json:"total"`
+	Next     string `json:"next,omitempty"`
+}
@@ -1,7 +1,18 @@
 package model
 
+import "time"
+
 type Pond struct {
-    ID      int     `json:"id,omitempty"`
-    Name    string  `json:"name,omitempty"`
-	FarmID	int		`json:"farm_id,omitempty"`
-}
\ No newline at end of file
+	ID             int        `json:"id,omitempty"`
+	Name           string     `json:"name,omitempty"`
+	FarmID         int        `json:"farm_id,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	Version        int        `json:"-"`
+	OrganizationID int        `json:"-"`
+}
+
+// PondExport is a pond joined with the name of its farm
+type PondExport struct {
+	Pond
+	FarmName string `json:"farm_name"`
+}
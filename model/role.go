@@ -0,0 +1,78 @@
+package model
+
+// Roles of an API key or bearer token, each granting the actions of the
+// policy table
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// Resources guarded by the policy table
+const (
+	ResourceFarm       = "farm"
+	ResourcePond       = "pond"
+	ResourceStatistics = "statistics"
+	ResourceLog        = "log"
+	ResourceMembership = "membership"
+)
+
+// Actions on a resource
+const (
+	ActionRead    = "read"
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// policy maps each role to the actions it may perform on each resource.
+// Reading covers the list, export and history endpoints and updating covers
+// both PUT and PATCH, a PUT creating a missing resource also needing create.
+var policy = map[string]map[string][]string{
+	RoleViewer: {
+		ResourceFarm:       {ActionRead},
+		ResourcePond:       {ActionRead},
+		ResourceStatistics: {ActionRead},
+	},
+	RoleOperator: {
+		ResourceFarm:       {ActionRead, ActionCreate, ActionUpdate},
+		ResourcePond:       {ActionRead, ActionCreate, ActionUpdate},
+		ResourceStatistics: {ActionRead},
+	},
+	RoleAdmin: {
+		ResourceFarm:       {ActionRead, ActionCreate, ActionUpdate, ActionDelete, ActionRestore},
+		ResourcePond:       {ActionRead, ActionCreate, ActionUpdate, ActionDelete, ActionRestore},
+		ResourceStatistics: {ActionRead},
+		ResourceLog:        {ActionRead},
+		ResourceMembership: {ActionRead, ActionCreate, ActionDelete},
+	},
+}
+
+// unrestrictedRoles reach every farm of their organization, the other roles
+// only the farms they are members of
+var unrestrictedRoles = map[string]bool{
+	RoleAdmin: true,
+}
+
+// IsValidRole reports whether role is in the policy table
+func IsValidRole(role string) bool {
+	_, ok := policy[role]
+	return ok
+}
+
+// RoleReachesAllFarms reports whether role reaches farms it is not a member of
+func RoleReachesAllFarms(role string) bool {
+	return unrestrictedRoles[role]
+}
+
+// RoleAllows reports whether role may perform action on resource, an unknown
+// role being allowed nothing
+func RoleAllows(role string, resource string, action string) bool {
+	for _, allowed := range policy[role][resource] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
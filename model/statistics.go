@@ -1,8 +1,60 @@
 package model
 
+import "time"
+
+// EndpointStatistics latencies are in microseconds and percentiles use the
+// nearest-rank method. ErrorRate is the share of 4xx and 5xx responses.
 type EndpointStatistics struct {
-	Count           int64 `json:"count"`
-	UniqueUserAgent int64 `json:"unique_user_agent"`
+	Count           int64   `json:"count"`
+	UniqueUserAgent int64   `json:"unique_user_agent"`
+	LatencyMin      int64   `gorm:"column:latency_min_us" json:"latency_min_us"`
+	LatencyMax      int64   `gorm:"column:latency_max_us" json:"latency_max_us"`
+	LatencyP50      int64   `gorm:"column:latency_p50_us" json:"latency_p50_us"`
+	LatencyP90      int64   `gorm:"column:latency_p90_us" json:"latency_p90_us"`
+	LatencyP99      int64   `gorm:"column:latency_p99_us" json:"latency_p99_us"`
+	Status2xx       int64   `gorm:"column:status_2xx" json:"status_2xx"`
+	Status4xx       int64   `gorm:"column:status_4xx" json:"status_4xx"`
+	Status5xx       int64   `gorm:"column:status_5xx" json:"status_5xx"`
+	ErrorRate       float64 `gorm:"-" json:"error_rate"`
+}
+
+// SetErrorRate derives ErrorRate from the status counts
+func (s *EndpointStatistics) SetErrorRate() {
+	s.ErrorRate = 0
+	if s.Count > 0 {
+		s.ErrorRate = float64(s.Status4xx+s.Status5xx) / float64(s.Count)
+	}
 }
 
-type Statistics map[string]*EndpointStatistics
\ No newline at end of file
+type Statistics map[string]*EndpointStatistics
+
+type StatisticsBucket string
+
+const (
+	BucketMinute StatisticsBucket = "minute"
+	BucketHour   StatisticsBucket = "hour"
+	BucketDay    StatisticsBucket = "day"
+)
+
+// Truncate rounds t down to the start of its bucket in UTC
+func (b StatisticsBucket) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch b {
+	case BucketMinute:
+		return t.Truncate(time.Minute)
+	case BucketHour:
+		return t.Truncate(time.Hour)
+	case BucketDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t
+}
+
+func (b StatisticsBucket) IsValid() bool {
+	return b == BucketMinute || b == BucketHour || b == BucketDay
+}
+
+type BucketStatistics struct {
+	Bucket    time.Time  `json:"bucket"`
+	Endpoints Statistics `json:"endpoints"`
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository interface {
+	Create(apiKey *model.ApiKey) error
+	List() ([]model.ApiKey, error)
+	GetActiveByHash(keyHash string) (*model.ApiKey, error)
+	Revoke(id int) error
+}
+
+type ApiKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) ApiKeyRepository {
+	return &ApiKeyRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *ApiKeyRepositoryImpl) Create(apiKey *model.ApiKey) error {
+	return r.db.Table("api_keys").Create(apiKey).Error
+}
+
+// List includes revoked keys, ordered by id
+func (r *ApiKeyRepositoryImpl) List() ([]model.ApiKey, error) {
+	apiKeys := make([]model.ApiKey, 0)
+	if err := r.db.Table("api_keys").Order("id").Scan(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (r *ApiKeyRepositoryImpl) GetActiveByHash(keyHash string) (*model.ApiKey, error) {
+	var apiKey *model.ApiKey
+	if err := r.db.Table("api_keys").Where("key_hash = ?", keyHash).Where("revoked_at IS NULL").First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// Revoke returns gorm.ErrRecordNotFound unless the key exists and is not
+// revoked yet
+func (r *ApiKeyRepositoryImpl) Revoke(id int) error {
+	result := r.db.Table("api_keys").Where("id = ?", id).Where("revoked_at IS NULL").Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
+)
+
+type AuditRepository interface {
+	Create(audit *model.Audit) error
+	GetByEntity(entity string, entityId int) ([]model.Audit, error)
+}
+
+type AuditRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &AuditRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *AuditRepositoryImpl) Create(audit *model.Audit) error {
+	return r.db.Table("audits").Create(audit).Error
+}
+
+func (r *AuditRepositoryImpl) GetByEntity(entity string, entityId int) ([]model.Audit, error) {
+	audits := make([]model.Audit, 0)
+	if err := r.db.Table("audits").Where("entity = ? AND entity_id = ?", entity, entityId).Order("id").Scan(&audits).Error; err != nil {
+		return nil, err
+	}
+	return audits, nil
+}
+
+// recordAudit stores the before and after snapshots of a mutation on db, the
+// transaction applying it. before is nil for creations.
+func recordAudit(db *gorm.DB, actor string, entity string, entityId int, operation string, before interface{}, after interface{}) error {
+	audit := model.Audit{
+		CreatedAt: time.Now().UTC(),
+		Entity:    entity,
+		EntityID:  entityId,
+		Operation: operation,
+		Actor:     actor,
+	}
+
+	var err error
+	if before != nil {
+		if audit.Before, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if audit.After, err = json.Marshal(after); err != nil {
+		return err
+	}
+	return NewAuditRepository(db).Create(&audit)
+}
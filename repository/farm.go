@@ -1,61 +1,417 @@
 package repository
 
 import (
-    "gorm.io/gorm"
-    "github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"errors"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
 )
 
 type FarmRepository interface {
-    GetByName(name string) (*model.Farm, error)
-    Create(farm *model.Farm) error
-    Get() ([]model.Farm, error)
-    GetById(id int) (*model.Farm, error)
-    Update(id int, farm *model.Farm) error
-    Delete(farm *model.Farm) error
+	GetByName(name string) (*model.Farm, error)
+	GetByNames(names []string) ([]model.Farm, error)
+	Create(farm *model.Farm) error
+	List(query model.ListQuery) ([]model.Farm, int64, error)
+	Export(each func(model.Farm) error) error
+	GetById(id int) (*model.Farm, error)
+	GetDeletedById(id int) (*model.Farm, error)
+	GetByIds(ids []int) ([]model.Farm, error)
+	Update(id int, farm *model.Farm) error
+	Delete(farm *model.Farm) error
+	Restore(farm *model.Farm) error
+	Bulk(items []model.FarmBulkItem, atomic bool) ([]error, error)
+	DeleteWithPonds(farm *model.Farm) error
+	CreateWithPonds(farms []*model.Farm, ponds []model.ImportPond) error
+	InOrganization(organizationId int) FarmRepository
+	ForMember(subject string) FarmRepository
+	AuditedBy(actor string) FarmRepository
 }
 
+// FarmRepositoryImpl only reaches the farms of its organization, none until
+// InOrganization picks one, and after ForMember only the farms subject is a
+// member of. After AuditedBy every mutation stores its audit in its own
+// transaction.
 type FarmRepositoryImpl struct {
-    db *gorm.DB
+	db             *gorm.DB
+	organizationId int
+	memberOnly     bool
+	subject        string
+	actor          string
 }
 
 func NewFarmRepository(db *gorm.DB) FarmRepository {
-    return &FarmRepositoryImpl{
-        db: db,
-    }
+	return &FarmRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *FarmRepositoryImpl) InOrganization(organizationId int) FarmRepository {
+	return &FarmRepositoryImpl{
+		db:             r.db,
+		organizationId: organizationId,
+	}
+}
+
+// ForMember returns a repository reaching only the farms subject is a member
+// of, granting subject the farms it creates
+func (r *FarmRepositoryImpl) ForMember(subject string) FarmRepository {
+	return &FarmRepositoryImpl{
+		db:             r.db,
+		organizationId: r.organizationId,
+		memberOnly:     true,
+		subject:        subject,
+	}
+}
+
+// AuditedBy returns a repository storing the audit of every mutation under
+// actor, committed along with the mutation
+func (r *FarmRepositoryImpl) AuditedBy(actor string) FarmRepository {
+	audited := *r
+	audited.actor = actor
+	return &audited
+}
+
+// withDB returns the repository running its queries on db, a transaction
+func (r *FarmRepositoryImpl) withDB(db *gorm.DB) *FarmRepositoryImpl {
+	scoped := *r
+	scoped.db = db
+	return &scoped
+}
+
+// pondsWithDB returns a pond repository with the same scope running its
+// queries on db
+func (r *FarmRepositoryImpl) pondsWithDB(db *gorm.DB) *PondRepositoryImpl {
+	return &PondRepositoryImpl{
+		db:             db,
+		organizationId: r.organizationId,
+		memberOnly:     r.memberOnly,
+		subject:        r.subject,
+		actor:          r.actor,
+	}
+}
+
+// audited runs mutate in a transaction when mutations are audited, so the
+// audit commits or rolls back along with the mutation
+func (r *FarmRepositoryImpl) audited(mutate func(r *FarmRepositoryImpl) error) error {
+	if r.actor == "" {
+		return mutate(r)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return mutate(r.withDB(tx))
+	})
+}
+
+// snapshot reads the farm with id before it is mutated, when mutations are
+// audited. A farm out of reach is a version conflict, as the mutation would
+// not apply.
+func (r *FarmRepositoryImpl) snapshot(id int) (*model.Farm, error) {
+	if r.actor == "" {
+		return nil, nil
+	}
+	var farm model.Farm
+	if err := r.table().Where("id = ?", id).First(&farm).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVersionConflict
+	} else if err != nil {
+		return nil, err
+	}
+	return &farm, nil
+}
+
+// audit stores the audit of a mutation of the farm with id, reading it back
+// as the after snapshot, when mutations are audited
+func (r *FarmRepositoryImpl) audit(id int, operation string, before *model.Farm) error {
+	if r.actor == "" {
+		return nil
+	}
+	var after model.Farm
+	if err := r.organization().Where("id = ?", id).First(&after).Error; err != nil {
+		return err
+	}
+	if before == nil {
+		return recordAudit(r.db, r.actor, model.AuditEntityFarm, id, operation, nil, after)
+	}
+	return recordAudit(r.db, r.actor, model.AuditEntityFarm, id, operation, before, after)
+}
+
+// organization scopes a query to the farms of the organization
+func (r *FarmRepositoryImpl) organization() *gorm.DB {
+	return r.db.Table("farms").Where("organization_id = ?", r.organizationId)
+}
+
+// table scopes a query to the farms of the organization the repository
+// reaches
+func (r *FarmRepositoryImpl) table() *gorm.DB {
+	db := r.organization()
+	if r.memberOnly {
+		db = db.Where("id IN (?)", memberFarmIds(r.db, r.subject))
+	}
+	return db
 }
 
+// GetByName includes deleted farms and farms subject is not a member of, as
+// their names stay reserved within the organization until they are restored
 func (r *FarmRepositoryImpl) GetByName(name string) (*model.Farm, error) {
-    var farm model.Farm
-    if err := r.db.Table("farms").Where("name = ?", name).First(&farm).Error; err != nil {
-        return nil, err
-    }
-    return &farm, nil
+	var farm model.Farm
+	if err := r.organization().Where("name = ?", name).First(&farm).Error; err != nil {
+		return nil, err
+	}
+	return &farm, nil
 }
 
+// GetByNames includes deleted farms, like GetByName
+func (r *FarmRepositoryImpl) GetByNames(names []string) ([]model.Farm, error) {
+	farms := make([]model.Farm, 0)
+	if err := r.organization().Where("name IN ?", names).Scan(&farms).Error; err != nil {
+		return nil, err
+	}
+	return farms, nil
+}
+
+// Create audits the creation of a farm at a preset id, which PUT requests
+// make, as an upsert
 func (r *FarmRepositoryImpl) Create(farm *model.Farm) error {
-    return r.db.Create(farm).Error
+	explicitId := farm.ID != 0
+	if explicitId {
+		if err := checkIdFree(r.db, "farms", farm.ID, r.table()); err != nil {
+			return err
+		}
+	}
+	if r.memberOnly {
+		// Grant the creator the farm
+		return r.db.Transaction(func(tx *gorm.DB) error {
+			creator := r.withDB(tx)
+			creator.memberOnly = false
+			if err := creator.Create(farm); err != nil {
+				return err
+			}
+			return NewFarmMemberRepository(tx).Grant(&model.FarmMember{FarmID: farm.ID, Subject: r.subject, CreatedAt: time.Now().UTC()})
+		})
+	}
+	return r.audited(func(r *FarmRepositoryImpl) error {
+		if farm.Version == 0 {
+			farm.Version = 1
+		}
+		farm.OrganizationID = r.organizationId
+		if err := r.db.Create(farm).Error; err != nil {
+			return err
+		}
+		if !explicitId {
+			return r.audit(farm.ID, model.AuditCreate, nil)
+		}
+		if err := syncSequence(r.db, "farms"); err != nil {
+			return err
+		}
+		return r.audit(farm.ID, model.AuditUpsert, nil)
+	})
 }
 
-func (r *FarmRepositoryImpl) Get() ([]model.Farm, error) {
-    var farm []model.Farm
-    if err := r.db.Table("farms").Scan(&farm).Error; err != nil {
-        return nil, err
-    }
-    return farm, nil
+func (r *FarmRepositoryImpl) List(query model.ListQuery) ([]model.Farm, int64, error) {
+	filter := func() *gorm.DB {
+		db := r.table()
+		if !query.IncludeDeleted {
+			db = db.Where(notDeleted)
+		}
+		if query.NameContains != "" {
+			db = db.Where(nameContains, containsPattern(query.NameContains))
+		}
+		return db
+	}
+
+	var total int64
+	if err := filter().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	farms := make([]model.Farm, 0)
+	if err := paginate(filter(), query).Scan(&farms).Error; err != nil {
+		return nil, 0, err
+	}
+	return farms, total, nil
+}
+
+// Export streams every farm ordered by id
+func (r *FarmRepositoryImpl) Export(each func(model.Farm) error) error {
+	return stream(r.table().Where(notDeleted).Order("id"), each)
 }
 
 func (r *FarmRepositoryImpl) GetById(id int) (*model.Farm, error) {
-    var farm *model.Farm
-    if err := r.db.Table("farms").Where("id = ?", id).First(&farm).Error; err != nil {
-        return nil, err
-    }
-    return farm, nil
+	var farm *model.Farm
+	if err := r.table().Where("id = ?", id).Where(notDeleted).First(&farm).Error; err != nil {
+		return nil, err
+	}
+	return farm, nil
+}
+
+func (r *FarmRepositoryImpl) GetDeletedById(id int) (*model.Farm, error) {
+	var farm *model.Farm
+	if err := r.table().Where("id = ?", id).Where("deleted_at IS NOT NULL").First(&farm).Error; err != nil {
+		return nil, err
+	}
+	return farm, nil
+}
+
+func (r *FarmRepositoryImpl) GetByIds(ids []int) ([]model.Farm, error) {
+	farms := make([]model.Farm, 0)
+	if err := r.table().Where("id IN ?", ids).Where(notDeleted).Order("id").Scan(&farms).Error; err != nil {
+		return nil, err
+	}
+	return farms, nil
 }
 
+// Update only applies if the farm is still at farm.Version, returning
+// ErrVersionConflict otherwise
 func (r *FarmRepositoryImpl) Update(id int, farm *model.Farm) error {
-    return r.db.Table("farms").Where("id = ?", id).Updates(model.Pond{Name: farm.Name}).Error
+	return r.audited(func(r *FarmRepositoryImpl) error {
+		before, err := r.snapshot(id)
+		if err != nil {
+			return err
+		}
+		if err := updateVersion(r.table().Where("id = ?", id), &farm.Version, map[string]interface{}{"name": farm.Name}); err != nil {
+			return err
+		}
+		return r.audit(id, model.AuditUpdate, before)
+	})
 }
 
+// Delete only applies if the farm is still at farm.Version, returning
+// ErrVersionConflict otherwise, and ErrFarmHasPonds if a pond not deleted
+// still references it. Both are checked by the statement soft deleting the
+// farm, so neither a concurrent update nor a pond created meanwhile is lost.
 func (r *FarmRepositoryImpl) Delete(farm *model.Farm) error {
-    return r.db.Table("farms").Delete(&farm).Error
-}
\ No newline at end of file
+	return r.audited(func(r *FarmRepositoryImpl) error {
+		before, err := r.snapshot(farm.ID)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		err = updateVersion(r.table().Where("id = ?", farm.ID).Where("NOT EXISTS (?)", r.livePonds()), &farm.Version, map[string]interface{}{"deleted_at": now})
+		if errors.Is(err, ErrVersionConflict) {
+			var count int64
+			if err := r.table().Where("id = ?", farm.ID).Where("EXISTS (?)", r.livePonds()).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrFarmHasPonds
+			}
+		}
+		if err != nil {
+			return err
+		}
+		farm.DeletedAt = &now
+		return r.audit(farm.ID, model.AuditDelete, before)
+	})
+}
+
+// livePonds selects the ponds not deleted of the farms of the outer query
+func (r *FarmRepositoryImpl) livePonds() *gorm.DB {
+	return r.db.Table("ponds").Select("1").Where("ponds.farm_id = farms.id").Where("ponds.deleted_at IS NULL")
+}
+
+// Restore only applies if the farm is still at farm.Version, returning
+// ErrVersionConflict otherwise
+func (r *FarmRepositoryImpl) Restore(farm *model.Farm) error {
+	return r.audited(func(r *FarmRepositoryImpl) error {
+		before, err := r.snapshot(farm.ID)
+		if err != nil {
+			return err
+		}
+		if err := updateVersion(r.table().Where("id = ?", farm.ID), &farm.Version, map[string]interface{}{"deleted_at": nil}); err != nil {
+			return err
+		}
+		farm.DeletedAt = nil
+		return r.audit(farm.ID, model.AuditRestore, before)
+	})
+}
+
+// DeleteWithPonds deletes the farm and every pond referencing it in a single
+// transaction, only if the farm is still at farm.Version, returning
+// ErrVersionConflict otherwise
+func (r *FarmRepositoryImpl) DeleteWithPonds(farm *model.Farm) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		farmRepository := r.withDB(tx)
+		before, err := farmRepository.snapshot(farm.ID)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		version := farm.Version
+		if err := updateVersion(farmRepository.table().Where("id = ?", farm.ID), &version, map[string]interface{}{"deleted_at": now}); err != nil {
+			return err
+		}
+
+		// Deleted ponds
+		pondRepository := r.pondsWithDB(tx)
+		ponds := make([]model.Pond, 0)
+		if r.actor != "" {
+			if err := pondRepository.table().Where("farm_id = ?", farm.ID).Where(notDeleted).Order("id").Scan(&ponds).Error; err != nil {
+				return err
+			}
+		}
+		var deletedAt *time.Time
+		if err := softDelete(pondRepository.table().Where("farm_id = ?", farm.ID).Where(notDeleted), &deletedAt); err != nil {
+			return err
+		}
+		for i := range ponds {
+			if err := pondRepository.audit(ponds[i].ID, model.AuditDelete, &ponds[i]); err != nil {
+				return err
+			}
+		}
+
+		farm.DeletedAt = &now
+		farm.Version = version
+		return farmRepository.audit(farm.ID, model.AuditDelete, before)
+	})
+}
+
+// CreateWithPonds creates the farms and then the ponds in a single
+// transaction, linking each pond to its farm once the farm has an id
+func (r *FarmRepositoryImpl) CreateWithPonds(farms []*model.Farm, ponds []model.ImportPond) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		farmRepository := r.withDB(tx)
+		for _, farm := range farms {
+			if err := farmRepository.Create(farm); err != nil {
+				return err
+			}
+		}
+
+		pondRepository := r.pondsWithDB(tx)
+		for _, pond := range ponds {
+			pond.Pond.FarmID = pond.Farm.ID
+			if err := pondRepository.Create(pond.Pond); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Bulk applies the items in a single transaction. When atomic the first
+// failing item rolls back the whole batch, otherwise each item runs in its own
+// savepoint so only the failing ones are rolled back.
+func (r *FarmRepositoryImpl) Bulk(items []model.FarmBulkItem, atomic bool) ([]error, error) {
+	errs := make([]error, len(items))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			errs[i] = tx.Transaction(func(tx *gorm.DB) error {
+				return r.withDB(tx).apply(&items[i])
+			})
+			if errs[i] != nil && atomic {
+				return errs[i]
+			}
+		}
+		return nil
+	})
+	return errs, err
+}
+
+func (r *FarmRepositoryImpl) apply(item *model.FarmBulkItem) error {
+	switch item.Operation {
+	case model.BulkCreate:
+		return r.Create(&item.Farm)
+	case model.BulkUpdate:
+		return r.Update(item.ID, &item.Farm)
+	default:
+		return r.Delete(&item.Farm)
+	}
+}
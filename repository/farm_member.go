@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
+)
+
+type FarmMemberRepository interface {
+	Grant(member *model.FarmMember) error
+	Revoke(farmId int, subject string) error
+	Get(farmId int, subject string) (*model.FarmMember, error)
+	GetByFarmId(farmId int) ([]model.FarmMember, error)
+}
+
+type FarmMemberRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewFarmMemberRepository(db *gorm.DB) FarmMemberRepository {
+	return &FarmMemberRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *FarmMemberRepositoryImpl) Grant(member *model.FarmMember) error {
+	return r.db.Table("farm_members").Create(member).Error
+}
+
+// Revoke returns gorm.ErrRecordNotFound unless subject is a member of the farm
+func (r *FarmMemberRepositoryImpl) Revoke(farmId int, subject string) error {
+	result := r.db.Table("farm_members").Where("farm_id = ? AND subject = ?", farmId, subject).Delete(&model.FarmMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *FarmMemberRepositoryImpl) Get(farmId int, subject string) (*model.FarmMember, error) {
+	var member *model.FarmMember
+	if err := r.db.Table("farm_members").Where("farm_id = ? AND subject = ?", farmId, subject).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// GetByFarmId returns the members of a farm ordered by subject
+func (r *FarmMemberRepositoryImpl) GetByFarmId(farmId int) ([]model.FarmMember, error) {
+	members := make([]model.FarmMember, 0)
+	if err := r.db.Table("farm_members").Where("farm_id = ?", farmId).Order("subject").Scan(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
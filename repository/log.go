@@ -1,50 +1,201 @@
 package repository
 
 import (
-    "gorm.io/gorm"
-    "github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"fmt"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
 )
 
 type LogRepository interface {
-    Create(log *model.Log) error
-    GetDistinctEndpoints() ([]string, error)
-    GetEndpointStatistics(endpoint string) (*model.EndpointStatistics, error)
+	Create(log *model.Log) error
+	GetStatistics() (model.Statistics, error)
+	GetBucketStatistics(from time.Time, to time.Time, bucket model.StatisticsBucket) ([]model.BucketStatistics, error)
+	Export(from time.Time, to time.Time, each func(model.Log) error) error
+	InOrganization(organizationId int) LogRepository
+}
+
+// bucketExpressions truncates created_at to the format bound as its argument,
+// per gorm dialect
+var bucketExpressions = map[string]string{
+	"mysql":    "DATE_FORMAT(created_at, ?)",
+	"postgres": "to_char(created_at, ?)",
+	"sqlite":   "strftime(?, created_at)",
+}
+
+var bucketFormats = map[string]map[model.StatisticsBucket]string{
+	"mysql": {
+		model.BucketMinute: "%Y-%m-%d %H:%i:00",
+		model.BucketHour:   "%Y-%m-%d %H:00:00",
+		model.BucketDay:    "%Y-%m-%d 00:00:00",
+	},
+	"postgres": {
+		model.BucketMinute: "YYYY-MM-DD HH24:MI:00",
+		model.BucketHour:   "YYYY-MM-DD HH24:00:00",
+		model.BucketDay:    "YYYY-MM-DD 00:00:00",
+	},
+	"sqlite": {
+		model.BucketMinute: "%Y-%m-%d %H:%M:00",
+		model.BucketHour:   "%Y-%m-%d %H:00:00",
+		model.BucketDay:    "%Y-%m-%d 00:00:00",
+	},
 }
 
+const bucketLayout = "2006-01-02 15:04:05"
+
+// LogRepositoryImpl reads the logs of a single organization, none until
+// InOrganization picks one. Create records logs in that organization, or in
+// none for a request that was not authenticated.
 type LogRepositoryImpl struct {
-    db *gorm.DB
+	db             *gorm.DB
+	organizationId int
 }
 
 func NewLogRepository(db *gorm.DB) LogRepository {
-    return &LogRepositoryImpl{
-        db: db,
-    }
+	return &LogRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *LogRepositoryImpl) InOrganization(organizationId int) LogRepository {
+	return &LogRepositoryImpl{
+		db:             r.db,
+		organizationId: organizationId,
+	}
+}
+
+// table scopes a query to the logs of the organization the repository is in
+func (r *LogRepositoryImpl) table() *gorm.DB {
+	return r.db.Table("logs").Where("organization_id = ?", r.organizationId)
 }
 
 func (r *LogRepositoryImpl) Create(log *model.Log) error {
-    return r.db.Create(log).Error
+	log.OrganizationID = nil
+	if r.organizationId != 0 {
+		organizationId := r.organizationId
+		log.OrganizationID = &organizationId
+	}
+	return r.db.Table("logs").Create(log).Error
 }
 
-func (r *LogRepositoryImpl) GetDistinctEndpoints() ([]string, error) {
-    var endpoints []string
-    if err := r.db.Table("logs").Distinct("endpoint").Pluck("endpoint", &endpoints).Error; err != nil {
-        return nil, err
-    }
-    return endpoints, nil
+// Export streams the logs created within [from, to) ordered by id, where a
+// zero time leaves that side open
+func (r *LogRepositoryImpl) Export(from time.Time, to time.Time, each func(model.Log) error) error {
+	db := r.table()
+	if !from.IsZero() {
+		db = db.Where("created_at >= ?", from.UTC())
+	}
+	if !to.IsZero() {
+		db = db.Where("created_at < ?", to.UTC())
+	}
+	return stream(db.Order("id"), each)
 }
 
-func (r *LogRepositoryImpl) GetEndpointStatistics(endpoint string) (*model.EndpointStatistics, error) {
-    var endpointStatistics *model.EndpointStatistics
-    query := `
-		SELECT 
+// GetStatistics aggregates the logs of every endpoint in a single query
+func (r *LogRepositoryImpl) GetStatistics() (model.Statistics, error) {
+	var rows []struct {
+		Endpoint string
+		model.EndpointStatistics
+	}
+	query := statisticsQuery("SELECT * FROM logs WHERE organization_id = ?", "endpoint")
+	if err := r.db.Raw(query, r.organizationId).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	statistics := make(model.Statistics)
+	for i := range rows {
+		rows[i].SetErrorRate()
+		statistics[rows[i].Endpoint] = &rows[i].EndpointStatistics
+	}
+	return statistics, nil
+}
+
+// GetBucketStatistics aggregates the logs per endpoint for every bucket in
+// [from, to). A zero from or to leaves that side unbounded.
+func (r *LogRepositoryImpl) GetBucketStatistics(from time.Time, to time.Time, bucket model.StatisticsBucket) ([]model.BucketStatistics, error) {
+	dialect := r.db.Dialector.Name()
+	expression, ok := bucketExpressions[dialect]
+	if !ok {
+		return nil, fmt.Errorf("statistics buckets are not supported on %s", dialect)
+	}
+	format, ok := bucketFormats[dialect][bucket]
+	if !ok {
+		return nil, fmt.Errorf("invalid statistics bucket %q", bucket)
+	}
+
+	source := "SELECT logs.*, " + expression + " AS bucket FROM logs WHERE organization_id = ?"
+	args := []interface{}{format, r.organizationId}
+	if !from.IsZero() {
+		source += " AND created_at >= ?"
+		args = append(args, from.UTC())
+	}
+	if !to.IsZero() {
+		source += " AND created_at < ?"
+		args = append(args, to.UTC())
+	}
+
+	var rows []struct {
+		Bucket   string
+		Endpoint string
+		model.EndpointStatistics
+	}
+	query := statisticsQuery(source, "bucket, endpoint") + " ORDER BY bucket"
+	if err := r.db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	bucketStatistics := make([]model.BucketStatistics, 0)
+	for i := range rows {
+		bucketTime, err := time.ParseInLocation(bucketLayout, rows[i].Bucket, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		if len(bucketStatistics) == 0 || !bucketStatistics[len(bucketStatistics)-1].Bucket.Equal(bucketTime) {
+			bucketStatistics = append(bucketStatistics, model.BucketStatistics{
+				Bucket:    bucketTime,
+				Endpoints: make(model.Statistics),
+			})
+		}
+		rows[i].SetErrorRate()
+		bucketStatistics[len(bucketStatistics)-1].Endpoints[rows[i].Endpoint] = &rows[i].EndpointStatistics
+	}
+	return bucketStatistics, nil
+}
+
+// statisticsQuery aggregates the rows of source into model.EndpointStatistics
+// columns grouped by groupColumns, or into a single row when it is empty.
+// Latencies are ranked with a window function so percentiles use the
+// nearest-rank method. Statuses are counted rather than summed, as SUM returns
+// a numeric on Postgres.
+func statisticsQuery(source string, groupColumns string) string {
+	partition, selectColumns, groupBy := "", "", ""
+	if groupColumns != "" {
+		partition = "PARTITION BY " + groupColumns
+		selectColumns = groupColumns + ","
+		groupBy = "GROUP BY " + groupColumns
+	}
+	return fmt.Sprintf(`
+		SELECT %[2]s
 			COUNT(*) AS count,
-			COUNT(DISTINCT user_agent) AS unique_user_agent
-		FROM 
-			logs
-		WHERE 
-			endpoint = ?`
-    if err := r.db.Raw(query, endpoint).Scan(&endpointStatistics).Error; err != nil {
-        return nil, err
-    }
-    return endpointStatistics, nil
-}
\ No newline at end of file
+			COUNT(DISTINCT user_agent) AS unique_user_agent,
+			COALESCE(MIN(latency_us), 0) AS latency_min_us,
+			COALESCE(MAX(latency_us), 0) AS latency_max_us,
+			COALESCE(MIN(CASE WHEN latency_rank >= 0.50 * latency_total THEN latency_us END), 0) AS latency_p50_us,
+			COALESCE(MIN(CASE WHEN latency_rank >= 0.90 * latency_total THEN latency_us END), 0) AS latency_p90_us,
+			COALESCE(MIN(CASE WHEN latency_rank >= 0.99 * latency_total THEN latency_us END), 0) AS latency_p99_us,
+			COUNT(CASE WHEN status_code >= 200 AND status_code < 300 THEN 1 END) AS status_2xx,
+			COUNT(CASE WHEN status_code >= 400 AND status_code < 500 THEN 1 END) AS status_4xx,
+			COUNT(CASE WHEN status_code >= 500 THEN 1 END) AS status_5xx
+		FROM (
+			SELECT %[2]s
+				user_agent,
+				latency_us,
+				status_code,
+				ROW_NUMBER() OVER (%[1]s ORDER BY latency_us) AS latency_rank,
+				COUNT(*) OVER (%[1]s) AS latency_total
+			FROM 
+				(%[4]s) source_logs
+		) ranked_logs
+		%[3]s`, partition, selectColumns, groupBy, source)
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository interface {
+	Create(organization *model.Organization) error
+	List() ([]model.Organization, error)
+	GetByName(name string) (*model.Organization, error)
+}
+
+type OrganizationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &OrganizationRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *OrganizationRepositoryImpl) Create(organization *model.Organization) error {
+	return r.db.Table("organizations").Create(organization).Error
+}
+
+// List returns every organization ordered by id
+func (r *OrganizationRepositoryImpl) List() ([]model.Organization, error) {
+	organizations := make([]model.Organization, 0)
+	if err := r.db.Table("organizations").Order("id").Scan(&organizations).Error; err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+func (r *OrganizationRepositoryImpl) GetByName(name string) (*model.Organization, error) {
+	var organization *model.Organization
+	if err := r.db.Table("organizations").Where("name = ?", name).First(&organization).Error; err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
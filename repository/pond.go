@@ -1,61 +1,345 @@
 package repository
 
 import (
-    "gorm.io/gorm"
-    "github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"errors"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
 )
 
 type PondRepository interface {
-    GetByName(name string) (*model.Pond, error)
-    Create(pond *model.Pond) error
-    Get() ([]model.Pond, error)
-    GetById(id int) (*model.Pond, error)
-    Update(id int, pond *model.Pond) error
-    Delete(pond *model.Pond) error
+	GetByName(name string) (*model.Pond, error)
+	GetByNames(names []string) ([]model.Pond, error)
+	Create(pond *model.Pond) error
+	List(query model.ListQuery) ([]model.Pond, int64, error)
+	Export(each func(model.PondExport) error) error
+	GetById(id int) (*model.Pond, error)
+	GetDeletedById(id int) (*model.Pond, error)
+	GetByIds(ids []int) ([]model.Pond, error)
+	GetByFarmIds(farmIds []int) ([]model.Pond, error)
+	GetByFarmId(farmId int) ([]model.Pond, error)
+	Update(id int, pond *model.Pond) error
+	Delete(pond *model.Pond) error
+	Restore(pond *model.Pond) error
+	Bulk(items []model.PondBulkItem, atomic bool) ([]error, error)
+	InOrganization(organizationId int) PondRepository
+	ForMember(subject string) PondRepository
+	AuditedBy(actor string) PondRepository
 }
 
+// PondRepositoryImpl only reaches the ponds of its organization, none until
+// InOrganization picks one, and after ForMember only the ponds of the farms
+// subject is a member of. A pond always belongs to the organization of its
+// farm. After AuditedBy every mutation stores its audit in its own
+// transaction.
 type PondRepositoryImpl struct {
-    db *gorm.DB
+	db             *gorm.DB
+	organizationId int
+	memberOnly     bool
+	subject        string
+	actor          string
 }
 
 func NewPondRepository(db *gorm.DB) PondRepository {
-    return &PondRepositoryImpl{
-        db: db,
-    }
+	return &PondRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *PondRepositoryImpl) InOrganization(organizationId int) PondRepository {
+	return &PondRepositoryImpl{
+		db:             r.db,
+		organizationId: organizationId,
+	}
+}
+
+// ForMember returns a repository reaching only the ponds of the farms subject
+// is a member of
+func (r *PondRepositoryImpl) ForMember(subject string) PondRepository {
+	return &PondRepositoryImpl{
+		db:             r.db,
+		organizationId: r.organizationId,
+		memberOnly:     true,
+		subject:        subject,
+	}
+}
+
+// AuditedBy returns a repository storing the audit of every mutation under
+// actor, committed along with the mutation
+func (r *PondRepositoryImpl) AuditedBy(actor string) PondRepository {
+	audited := *r
+	audited.actor = actor
+	return &audited
+}
+
+// withDB returns the repository running its queries on db, a transaction
+func (r *PondRepositoryImpl) withDB(db *gorm.DB) *PondRepositoryImpl {
+	scoped := *r
+	scoped.db = db
+	return &scoped
+}
+
+// audited runs mutate in a transaction when mutations are audited, so the
+// audit commits or rolls back along with the mutation
+func (r *PondRepositoryImpl) audited(mutate func(r *PondRepositoryImpl) error) error {
+	if r.actor == "" {
+		return mutate(r)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return mutate(r.withDB(tx))
+	})
+}
+
+// snapshot reads the pond with id before it is mutated, when mutations are
+// audited. A pond out of reach is a version conflict, as the mutation would
+// not apply.
+func (r *PondRepositoryImpl) snapshot(id int) (*model.Pond, error) {
+	if r.actor == "" {
+		return nil, nil
+	}
+	var pond model.Pond
+	if err := r.table().Where("id = ?", id).First(&pond).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVersionConflict
+	} else if err != nil {
+		return nil, err
+	}
+	return &pond, nil
+}
+
+// audit stores the audit of a mutation of the pond with id, reading it back
+// as the after snapshot, when mutations are audited
+func (r *PondRepositoryImpl) audit(id int, operation string, before *model.Pond) error {
+	if r.actor == "" {
+		return nil
+	}
+	var after model.Pond
+	if err := r.organization().Where("id = ?", id).First(&after).Error; err != nil {
+		return err
+	}
+	if before == nil {
+		return recordAudit(r.db, r.actor, model.AuditEntityPond, id, operation, nil, after)
+	}
+	return recordAudit(r.db, r.actor, model.AuditEntityPond, id, operation, before, after)
 }
 
+// organization scopes a query to the ponds of the organization
+func (r *PondRepositoryImpl) organization() *gorm.DB {
+	return r.db.Table("ponds").Where("organization_id = ?", r.organizationId)
+}
+
+// table scopes a query to the ponds of the organization the repository
+// reaches
+func (r *PondRepositoryImpl) table() *gorm.DB {
+	db := r.organization()
+	if r.memberOnly {
+		db = db.Where("farm_id IN (?)", memberFarmIds(r.db, r.subject))
+	}
+	return db
+}
+
+// GetByName includes deleted ponds and ponds of farms subject is not a member
+// of, as their names stay reserved within the organization until they are
+// restored
 func (r *PondRepositoryImpl) GetByName(name string) (*model.Pond, error) {
-    var pond model.Pond
-    if err := r.db.Table("ponds").Where("name = ?", name).First(&pond).Error; err != nil {
-        return nil, err
-    }
-    return &pond, nil
+	var pond model.Pond
+	if err := r.organization().Where("name = ?", name).First(&pond).Error; err != nil {
+		return nil, err
+	}
+	return &pond, nil
+}
+
+// GetByNames includes deleted ponds, like GetByName
+func (r *PondRepositoryImpl) GetByNames(names []string) ([]model.Pond, error) {
+	ponds := make([]model.Pond, 0)
+	if err := r.organization().Where("name IN ?", names).Scan(&ponds).Error; err != nil {
+		return nil, err
+	}
+	return ponds, nil
 }
 
+// Create audits the creation of a pond at a preset id, which PUT requests
+// make, as an upsert
 func (r *PondRepositoryImpl) Create(pond *model.Pond) error {
-    return r.db.Create(pond).Error
+	if pond.Version == 0 {
+		pond.Version = 1
+	}
+	pond.OrganizationID = r.organizationId
+	explicitId := pond.ID != 0
+	if explicitId {
+		if err := checkIdFree(r.db, "ponds", pond.ID, r.table()); err != nil {
+			return err
+		}
+	}
+	return r.audited(func(r *PondRepositoryImpl) error {
+		if err := r.db.Create(pond).Error; err != nil {
+			return err
+		}
+		if !explicitId {
+			return r.audit(pond.ID, model.AuditCreate, nil)
+		}
+		if err := syncSequence(r.db, "ponds"); err != nil {
+			return err
+		}
+		return r.audit(pond.ID, model.AuditUpsert, nil)
+	})
+}
+
+func (r *PondRepositoryImpl) List(query model.ListQuery) ([]model.Pond, int64, error) {
+	filter := func() *gorm.DB {
+		db := r.table()
+		if !query.IncludeDeleted {
+			db = db.Where(notDeleted)
+		}
+		if query.NameContains != "" {
+			db = db.Where(nameContains, containsPattern(query.NameContains))
+		}
+		if query.FarmID != 0 {
+			db = db.Where("farm_id = ?", query.FarmID)
+		}
+		return db
+	}
+
+	var total int64
+	if err := filter().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	ponds := make([]model.Pond, 0)
+	if err := paginate(filter(), query).Scan(&ponds).Error; err != nil {
+		return nil, 0, err
+	}
+	return ponds, total, nil
 }
 
-func (r *PondRepositoryImpl) Get() ([]model.Pond, error) {
-    var pond []model.Pond
-    if err := r.db.Table("ponds").Scan(&pond).Error; err != nil {
-        return nil, err
-    }
-    return pond, nil
+// Export streams every pond ordered by id along with its farm name
+func (r *PondRepositoryImpl) Export(each func(model.PondExport) error) error {
+	db := r.db.Table("ponds").
+		Select("ponds.*, farms.name AS farm_name").
+		Joins("JOIN farms ON farms.id = ponds.farm_id").
+		Where("ponds.organization_id = ?", r.organizationId).
+		Where("ponds.deleted_at IS NULL").
+		Order("ponds.id")
+	if r.memberOnly {
+		db = db.Where("ponds.farm_id IN (?)", memberFarmIds(r.db, r.subject))
+	}
+	return stream(db, each)
 }
 
 func (r *PondRepositoryImpl) GetById(id int) (*model.Pond, error) {
-    var pond *model.Pond
-    if err := r.db.Table("ponds").Where("id = ?", id).First(&pond).Error; err != nil {
-        return nil, err
-    }
-    return pond, nil
+	var pond *model.Pond
+	if err := r.table().Where("id = ?", id).Where(notDeleted).First(&pond).Error; err != nil {
+		return nil, err
+	}
+	return pond, nil
+}
+
+func (r *PondRepositoryImpl) GetDeletedById(id int) (*model.Pond, error) {
+	var pond *model.Pond
+	if err := r.table().Where("id = ?", id).Where("deleted_at IS NOT NULL").First(&pond).Error; err != nil {
+		return nil, err
+	}
+	return pond, nil
+}
+
+func (r *PondRepositoryImpl) GetByFarmId(farmId int) ([]model.Pond, error) {
+	pond := make([]model.Pond, 0)
+	if err := r.table().Where("farm_id = ?", farmId).Where(notDeleted).Order("id").Scan(&pond).Error; err != nil {
+		return nil, err
+	}
+	return pond, nil
+}
+
+func (r *PondRepositoryImpl) GetByIds(ids []int) ([]model.Pond, error) {
+	ponds := make([]model.Pond, 0)
+	if err := r.table().Where("id IN ?", ids).Where(notDeleted).Order("id").Scan(&ponds).Error; err != nil {
+		return nil, err
+	}
+	return ponds, nil
+}
+
+func (r *PondRepositoryImpl) GetByFarmIds(farmIds []int) ([]model.Pond, error) {
+	pond := make([]model.Pond, 0)
+	if err := r.table().Where("farm_id IN ?", farmIds).Where(notDeleted).Order("id").Scan(&pond).Error; err != nil {
+		return nil, err
+	}
+	return pond, nil
 }
 
+// Update only applies if the pond is still at pond.Version, returning
+// ErrVersionConflict otherwise
 func (r *PondRepositoryImpl) Update(id int, pond *model.Pond) error {
-    return r.db.Table("ponds").Where("id = ?", id).Updates(model.Pond{Name: pond.Name, FarmID: pond.FarmID}).Error
+	return r.audited(func(r *PondRepositoryImpl) error {
+		before, err := r.snapshot(id)
+		if err != nil {
+			return err
+		}
+		if err := updateVersion(r.table().Where("id = ?", id), &pond.Version, map[string]interface{}{"name": pond.Name, "farm_id": pond.FarmID}); err != nil {
+			return err
+		}
+		return r.audit(id, model.AuditUpdate, before)
+	})
 }
 
+// Delete only applies if the pond is still at pond.Version, returning
+// ErrVersionConflict otherwise
 func (r *PondRepositoryImpl) Delete(pond *model.Pond) error {
-    return r.db.Table("ponds").Delete(&pond).Error
-}
\ No newline at end of file
+	return r.audited(func(r *PondRepositoryImpl) error {
+		before, err := r.snapshot(pond.ID)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if err := updateVersion(r.table().Where("id = ?", pond.ID), &pond.Version, map[string]interface{}{"deleted_at": now}); err != nil {
+			return err
+		}
+		pond.DeletedAt = &now
+		return r.audit(pond.ID, model.AuditDelete, before)
+	})
+}
+
+// Restore only applies if the pond is still at pond.Version, returning
+// ErrVersionConflict otherwise
+func (r *PondRepositoryImpl) Restore(pond *model.Pond) error {
+	return r.audited(func(r *PondRepositoryImpl) error {
+		before, err := r.snapshot(pond.ID)
+		if err != nil {
+			return err
+		}
+		if err := updateVersion(r.table().Where("id = ?", pond.ID), &pond.Version, map[string]interface{}{"deleted_at": nil}); err != nil {
+			return err
+		}
+		pond.DeletedAt = nil
+		return r.audit(pond.ID, model.AuditRestore, before)
+	})
+}
+
+// Bulk applies the items in a single transaction. When atomic the first
+// failing item rolls back the whole batch, otherwise each item runs in its own
+// savepoint so only the failing ones are rolled back.
+func (r *PondRepositoryImpl) Bulk(items []model.PondBulkItem, atomic bool) ([]error, error) {
+	errs := make([]error, len(items))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			errs[i] = tx.Transaction(func(tx *gorm.DB) error {
+				return r.withDB(tx).apply(&items[i])
+			})
+			if errs[i] != nil && atomic {
+				return errs[i]
+			}
+		}
+		return nil
+	})
+	return errs, err
+}
+
+func (r *PondRepositoryImpl) apply(item *model.PondBulkItem) error {
+	switch item.Operation {
+	case model.BulkCreate:
+		return r.Create(&item.Pond)
+	case model.BulkUpdate:
+		return r.Update(item.ID, &item.Pond)
+	default:
+		return r.Delete(&item.Pond)
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
+)
+
+// notDeleted excludes soft deleted rows
+const notDeleted = "deleted_at IS NULL"
+
+// ErrVersionConflict is returned when a row changed since its version was
+// read
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrFarmHasPonds is returned when deleting a farm that ponds not deleted
+// still reference
+var ErrFarmHasPonds = errors.New("farm still has ponds")
+
+// ErrIdTaken is returned when creating a row at an id that another
+// organization, or a farm out of reach of the member, already holds, ids being
+// shared by organizations
+var ErrIdTaken = errors.New("id taken out of reach")
+
+// nextVersion bumps the optimistic concurrency version of updated rows
+var nextVersion = gorm.Expr("version + 1")
+
+// updateVersion applies values to the row of db only if it is still at
+// version, advancing version on success
+func updateVersion(db *gorm.DB, version *int, values map[string]interface{}) error {
+	values["version"] = nextVersion
+	result := db.Where("version = ?", *version).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	*version++
+	return nil
+}
+
+// softDelete marks the rows of db as deleted, storing the deletion time in
+// deletedAt
+func softDelete(db *gorm.DB, deletedAt **time.Time) error {
+	now := time.Now().UTC()
+	if err := db.Updates(map[string]interface{}{"deleted_at": now, "version": nextVersion}).Error; err != nil {
+		return err
+	}
+	*deletedAt = &now
+	return nil
+}
+
+// memberFarmIds selects the ids of the farms subject is a member of
+func memberFarmIds(db *gorm.DB, subject string) *gorm.DB {
+	return db.Table("farm_members").Select("farm_id").Where("subject = ?", subject)
+}
+
+// checkIdFree returns ErrIdTaken if a row out of reachable, of another
+// organization or of a farm the member cannot reach, holds id in table
+func checkIdFree(db *gorm.DB, table string, id int, reachable *gorm.DB) error {
+	var count int64
+	if err := db.Table(table).Where("id = ?", id).Where("id NOT IN (?)", reachable.Select("id")).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrIdTaken
+	}
+	return nil
+}
+
+// syncSequence moves the id sequence of table past the ids inserted
+// explicitly, which Postgres does not do by itself unlike MySQL and SQLite
+func syncSequence(db *gorm.DB, table string) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return db.Exec("SELECT setval(pg_get_serial_sequence(?, 'id'), (SELECT MAX(id) FROM "+table+"))", table).Error
+}
+
+// nameContains filters on a containsPattern. It uses "!" as a portable LIKE
+// escape character, as backslash means different things to each database, and
+// lowers both sides as LIKE is case sensitive on Postgres.
+const nameContains = "LOWER(name) LIKE LOWER(?) ESCAPE '!'"
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// containsPattern builds a LIKE pattern matching value anywhere in a column
+func containsPattern(value string) string {
+	return "%" + likeEscaper.Replace(value) + "%"
+}
+
+// paginate applies the sorting and page window of query, using id as the
+// tie breaker so pages are stable
+func paginate(db *gorm.DB, query model.ListQuery) *gorm.DB {
+	column, desc := query.SortColumn()
+	order := column
+	if desc {
+		order += " DESC"
+	}
+	if column != "id" {
+		order += ", id"
+	}
+	return db.Order(order).Offset(query.Offset()).Limit(query.PageSize)
+}
+
+// stream scans the rows of db one at a time into each, so exports never hold
+// a whole table in memory
+func stream[T any](db *gorm.DB, each func(T) error) error {
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record T
+		if err := db.ScanRows(rows, &record); err != nil {
+			return err
+		}
+		if err := each(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,349 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createApiKey stores a key with scopes and role and returns it in clear
+func createApiKey(t *testing.T, apiKeyRepo *repository.MockApiKeyRepository, name string, scopes string, role string) string {
+	key, err := utility.GenerateApiKey()
+	assert.NoError(t, err)
+	assert.NoError(t, apiKeyRepo.Create(&model.ApiKey{
+		Name:           name,
+		Prefix:         key[:utility.ApiKeyPrefixLength],
+		KeyHash:        utility.HashApiKey(key),
+		Scopes:         scopes,
+		Role:           role,
+		OrganizationID: model.DefaultOrganizationID,
+		CreatedAt:      time.Now().UTC(),
+	}))
+	return key
+}
+
+func newAuthRouter(jwtVerifier *utility.JwtVerifier) (*gin.Engine, *repository.MockApiKeyRepository, *repository.MockAuditRepository, *repository.MockLogRepository) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	farmRepo.LinkAuditRepository(auditRepo)
+	logRepo := repository.NewMockLogRepository()
+	apiKeyRepo := repository.NewMockApiKeyRepository()
+
+	// Create handlers with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+	logHandler := handler.NewLogHandler(logRepo)
+	authHandler := handler.NewAuthHandler(apiKeyRepo, repository.NewMockOrganizationRepository(), jwtVerifier)
+
+	// Create a Gin router and set up the guarded handler routes
+	router := gin.Default()
+	router.Use(logHandler.LogRequest)
+	farmRouter := router.Group("/farm", authHandler.RequireScope(model.ScopeGroupFarm))
+	farmRouter.POST("/", farmHandler.CreateFarm)
+	farmRouter.GET("/", farmHandler.GetFarm)
+	return router, apiKeyRepo, auditRepo, logRepo
+}
+
+func TestRequireScope_ApiKey(t *testing.T) {
+	router, apiKeyRepo, auditRepo, logRepo := newAuthRouter(&utility.JwtVerifier{})
+
+	// Create keys for testing
+	reader := createApiKey(t, apiKeyRepo, "Reader", "farm:read,pond:write", model.RoleAdmin)
+	writer := createApiKey(t, apiKeyRepo, "Writer", "farm:write", model.RoleAdmin)
+	revoked := createApiKey(t, apiKeyRepo, "Revoked", "farm:write", model.RoleAdmin)
+	assert.NoError(t, apiKeyRepo.Revoke(3))
+
+	testCases := []struct {
+		name         string
+		method       string
+		key          string
+		expectedCode int
+		expectedBody string
+	}{
+		{"Missing key", "GET", "", http.StatusUnauthorized, "Missing API key or bearer token"},
+		{"Unknown key", "GET", "delos_unknown", http.StatusUnauthorized, "Invalid API key"},
+		{"Revoked key", "GET", revoked, http.StatusUnauthorized, "Invalid API key"},
+		{"Read scope reads", "GET", reader, http.StatusOK, ""},
+		{"Read scope writes", "POST", reader, http.StatusForbidden, "API key lacks the farm:write scope"},
+		{"Write scope reads", "GET", writer, http.StatusOK, ""},
+		{"Write scope writes", "POST", writer, http.StatusOK, ""},
+	}
+	for _, testCase := range testCases {
+		// Perform the request
+		request, _ := http.NewRequest(testCase.method, "/farm/", strings.NewReader(`{"name": "Farm 1"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Actor", "Impostor")
+		if testCase.key != "" {
+			request.Header.Set(handler.ApiKeyHeader, testCase.key)
+		}
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the response
+		assert.Equal(t, testCase.expectedCode, responseRecorder.Code, testCase.name)
+		assert.Contains(t, responseRecorder.Body.String(), testCase.expectedBody, testCase.name)
+	}
+
+	// Check the member subject of the key, not its name, is the audit actor
+	// and the logged subject, whatever the request claims
+	audits, _ := auditRepo.GetByEntity(model.AuditEntityFarm, 1)
+	assert.Len(t, audits, 1)
+	assert.Equal(t, model.ApiKeyMember(2), audits[0].Actor)
+	assert.Equal(t, model.ApiKeyMember(2), logRepo.Logs()[len(testCases)-1].Subject)
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := model.ParseScopes(" farm:read, pond:write,,farm:read")
+	assert.NoError(t, err)
+	assert.Equal(t, "farm:read,pond:write", scopes)
+
+	for _, invalid := range []string{"", "farm", "farm:delete", "audit:read"} {
+		_, err := model.ParseScopes(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+const (
+	testIssuerSecret = "https://secret.example.com"
+	testIssuerJwks   = "https://jwks.example.com"
+	testIssuerPem    = "https://pem.example.com"
+	testSecret       = "0123456789abcdef0123456789abcdef"
+)
+
+// newJwtVerifier configures an HS256 issuer, an RS256 issuer with a JWKS file
+// and an RS256 issuer with a PEM public key, returning the RSA private key
+func newJwtVerifier(t *testing.T) (*utility.JwtVerifier, *rsa.PrivateKey) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// Write the public key as a JWKS file and a PEM file
+	appPath := t.TempDir()
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256"},
+			{
+				"kty": "RSA",
+				"kid": "rsa-1",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+			},
+		},
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "jwks.json"), jwks, 0600))
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "public.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), 0600))
+
+	var config utility.Configuration
+	config.AppPath = appPath
+	require.NoError(t, json.Unmarshal([]byte(`{"auth": {"jwt_issuers": [
+		{"issuer": "`+testIssuerSecret+`", "audience": "delos", "hs256_secret": "`+testSecret+`"},
+		{"issuer": "`+testIssuerJwks+`", "jwks_file": "jwks.json"},
+		{"issuer": "`+testIssuerPem+`", "rs256_public_key_file": "public.pem"}
+	]}}`), &config))
+	verifier, err := utility.NewJwtVerifier(config)
+	require.NoError(t, err)
+	return verifier, privateKey
+}
+
+// signToken signs claims with method, using kid when set
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestRequireScope_BearerToken(t *testing.T) {
+	verifier, privateKey := newJwtVerifier(t)
+	router, _, auditRepo, logRepo := newAuthRouter(verifier)
+
+	// Build claims for testing, dropping the claims set to nil
+	expiry := time.Now().Add(time.Hour).Unix()
+	claims := func(issuer string, overrides jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{"iss": issuer, "sub": "user-1", "aud": "delos", "exp": expiry, "scope": "farm:write", "role": model.RoleAdmin, "org": "default"}
+		for name, value := range overrides {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+	hs256 := func(overrides jwt.MapClaims) string {
+		return signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(testIssuerSecret, overrides))
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		method        string
+		authorization string
+		expectedCode  int
+		expectedBody  string
+	}{
+		{"HS256 token", "POST", "Bearer " + hs256(nil), http.StatusOK, ""},
+		{"HS256 token with a list audience", "GET", "Bearer " + hs256(jwt.MapClaims{"aud": []string{"other", "delos"}}), http.StatusOK, ""},
+		{"RS256 token from the JWKS file", "POST", "Bearer " + signToken(t, jwt.SigningMethodRS256, privateKey, "rsa-1", claims(testIssuerJwks, nil)), http.StatusOK, ""},
+		{"RS256 token from the PEM file", "GET", "Bearer " + signToken(t, jwt.SigningMethodRS256, privateKey, "", claims(testIssuerPem, nil)), http.StatusOK, ""},
+		{"Read scope writes", "POST", "Bearer " + hs256(jwt.MapClaims{"scope": "pond:write farm:read"}), http.StatusForbidden, "Bearer token lacks the farm:write scope"},
+		{"Missing scope", "GET", "Bearer " + hs256(jwt.MapClaims{"scope": nil}), http.StatusForbidden, "Bearer token lacks the farm:read scope"},
+		{"Missing organization", "GET", "Bearer " + hs256(jwt.MapClaims{"org": nil}), http.StatusForbidden, "Bearer token lacks the org claim"},
+		{"Unknown organization", "GET", "Bearer " + hs256(jwt.MapClaims{"org": "other"}), http.StatusForbidden, "Unknown organization other"},
+		{"Basic credentials", "GET", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "Missing API key or bearer token"},
+		{"Malformed token", "GET", "Bearer not-a-token", http.StatusUnauthorized, "Invalid bearer token"},
+		{"Wrong secret", "GET", "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret+"!"), "", claims(testIssuerSecret, nil)), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Wrong RSA key", "GET", "Bearer " + signToken(t, jwt.SigningMethodRS256, otherKey, "rsa-1", claims(testIssuerJwks, nil)), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Unknown kid", "GET", "Bearer " + signToken(t, jwt.SigningMethodRS256, privateKey, "rsa-2", claims(testIssuerJwks, nil)), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Algorithm of another issuer", "GET", "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(testIssuerJwks, nil)), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Unsupported algorithm", "GET", "Bearer " + signToken(t, jwt.SigningMethodHS512, []byte(testSecret), "", claims(testIssuerSecret, nil)), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Unsigned token", "GET", "Bearer " + signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", claims(testIssuerSecret, nil)), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Unknown issuer", "GET", "Bearer " + hs256(jwt.MapClaims{"iss": "https://other.example.com"}), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Wrong audience", "GET", "Bearer " + hs256(jwt.MapClaims{"aud": "other"}), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Missing audience", "GET", "Bearer " + hs256(jwt.MapClaims{"aud": nil}), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Expired token", "GET", "Bearer " + hs256(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Missing expiry", "GET", "Bearer " + hs256(jwt.MapClaims{"exp": nil}), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Future token", "GET", "Bearer " + hs256(jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()}), http.StatusUnauthorized, "Invalid bearer token"},
+		{"Missing subject", "GET", "Bearer " + hs256(jwt.MapClaims{"sub": nil}), http.StatusUnauthorized, "Invalid bearer token"},
+	}
+	for i, testCase := range testCases {
+		// Perform the request
+		request, _ := http.NewRequest(testCase.method, "/farm/", strings.NewReader(fmt.Sprintf(`{"name": "Farm %d"}`, i+1)))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("User-Agent", "Test Agent")
+		request.Header.Set("X-Actor", "Impostor")
+		request.Header.Set("Authorization", testCase.authorization)
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the response
+		assert.Equal(t, testCase.expectedCode, responseRecorder.Code, testCase.name)
+		assert.Contains(t, responseRecorder.Body.String(), testCase.expectedBody, testCase.name)
+		if testCase.expectedCode == http.StatusUnauthorized {
+			assert.Contains(t, responseRecorder.Header().Get("WWW-Authenticate"), "Bearer", testCase.name)
+		}
+	}
+
+	// Check the subject is logged along with its issuer and the user agent
+	logs := logRepo.Logs()
+	require.Len(t, logs, len(testCases))
+	for i, testCase := range testCases {
+		if testCase.expectedCode == http.StatusUnauthorized {
+			assert.Empty(t, logs[i].Subject, testCase.name)
+		} else {
+			assert.Regexp(t, `^https://\w+\.example\.com\|user-1$`, logs[i].Subject, testCase.name)
+		}
+		assert.Equal(t, "Test Agent", logs[i].UserAgent, testCase.name)
+	}
+
+	// Check the subject along with its issuer is the audit actor of both
+	// created farms, whatever the request claims
+	for farmId, issuer := range map[int]string{1: testIssuerSecret, 2: testIssuerJwks} {
+		audits, _ := auditRepo.GetByEntity(model.AuditEntityFarm, farmId)
+		require.Len(t, audits, 1)
+		assert.Equal(t, model.TokenMember(issuer, "user-1"), audits[0].Actor)
+	}
+}
+
+func TestRequireScope_Claims(t *testing.T) {
+	verifier, _ := newJwtVerifier(t)
+	apiKeyRepo := repository.NewMockApiKeyRepository()
+	authHandler := handler.NewAuthHandler(apiKeyRepo, repository.NewMockOrganizationRepository(), verifier)
+
+	// Create a Gin router exposing the context of the request
+	router := gin.Default()
+	router.GET("/farm", authHandler.RequireScope(model.ScopeGroupFarm), func(c *gin.Context) {
+		claims, _ := c.Get(handler.ContextClaims)
+		c.JSON(http.StatusOK, gin.H{"subject": c.GetString(handler.ContextSubject), "member": c.GetString(handler.ContextMember), "role": c.GetString(handler.ContextRole), "organization": c.GetInt(handler.ContextOrganization), "claims": claims})
+	})
+
+	// Perform the request
+	token := signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{
+		"iss":   testIssuerSecret,
+		"sub":   "user-1",
+		"aud":   "delos",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "farm:read",
+		"role":  model.RoleViewer,
+		"org":   "default",
+		"name":  "User One",
+	})
+	request, _ := http.NewRequest("GET", "/farm", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the subject and claims are in the context
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	var response struct {
+		Subject      string                 `json:"subject"`
+		Member       string                 `json:"member"`
+		Role         string                 `json:"role"`
+		Organization int                    `json:"organization"`
+		Claims       map[string]interface{} `json:"claims"`
+	}
+	require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+	assert.Equal(t, "user-1", response.Subject)
+	assert.Equal(t, testIssuerSecret+"|user-1", response.Member)
+	assert.Equal(t, model.RoleViewer, response.Role)
+	assert.Equal(t, model.DefaultOrganizationID, response.Organization)
+	assert.Equal(t, "User One", response.Claims["name"])
+	assert.Equal(t, testIssuerSecret, response.Claims["iss"])
+
+	// Check an API key is a member by its id rather than its name
+	createApiKey(t, apiKeyRepo, "user-1", "farm:read", model.RoleViewer)
+	request, _ = http.NewRequest("GET", "/farm", nil)
+	request.Header.Set(handler.ApiKeyHeader, createApiKey(t, apiKeyRepo, "user-1", "farm:read", model.RoleViewer))
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+	assert.Equal(t, "user-1", response.Subject)
+	assert.Equal(t, "apikey:2", response.Member)
+}
+
+func TestNewJwtVerifier_InvalidConfiguration(t *testing.T) {
+	for name, issuers := range map[string]string{
+		"Missing issuer": `[{"hs256_secret": "` + testSecret + `"}]`,
+		"Missing key":    `[{"issuer": "a"}]`,
+		"Short secret":   `[{"issuer": "a", "hs256_secret": "secret"}]`,
+		"Duplicate":      `[{"issuer": "a", "hs256_secret": "` + testSecret + `"}, {"issuer": "a", "hs256_secret": "` + testSecret + `"}]`,
+		"Missing PEM":    `[{"issuer": "a", "rs256_public_key_file": "missing.pem"}]`,
+		"Missing JWKS":   `[{"issuer": "a", "jwks_file": "missing.json"}]`,
+	} {
+		var config utility.Configuration
+		config.AppPath = t.TempDir()
+		require.NoError(t, json.Unmarshal([]byte(`{"auth": {"jwt_issuers": `+issuers+`}}`), &config))
+		_, err := utility.NewJwtVerifier(config)
+		assert.Error(t, err, name)
+	}
+}
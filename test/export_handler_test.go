@@ -0,0 +1,291 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	gormRepository "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportFarm_CSV(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm/export", farmHandler.ExportFarm)
+
+	// Create farms for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farmRepo.Create(&model.Farm{Name: "Farm, 2"})
+	farmRepo.Create(&model.Farm{Name: "Farm 3"})
+	farmRepo.Delete(&model.Farm{ID: 3, Version: 1})
+
+	// Perform the request
+	request, _ := http.NewRequest("GET", "/farm/export", nil)
+	request.Header.Set("Accept", "text/csv")
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", responseRecorder.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="farms.csv"`, responseRecorder.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,name\n1,Farm 1\n2,\"Farm, 2\"\n", responseRecorder.Body.String())
+}
+
+func TestExportPond_NDJSON(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	farmRepo.LinkPondRepository(pondRepo)
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/pond/export", pondHandler.ExportPond)
+
+	// Create farms and ponds for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farmRepo.Create(&model.Farm{Name: "Farm 2"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 2})
+	pondRepo.Create(&model.Pond{Name: "Pond 2", FarmID: 1})
+
+	// Perform the request without an Accept header
+	request, _ := http.NewRequest("GET", "/pond/export", nil)
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, "application/x-ndjson", responseRecorder.Header().Get("Content-Type"))
+	expectedBody := `{"id":1,"name":"Pond 1","farm_id":2,"farm_name":"Farm 2"}` + "\n" +
+		`{"id":2,"name":"Pond 2","farm_id":1,"farm_name":"Farm 1"}` + "\n"
+	assert.Equal(t, expectedBody, responseRecorder.Body.String())
+}
+
+func TestExportLog(t *testing.T) {
+	// Create a mock repository
+	logRepo := repository.NewMockLogRepository()
+
+	// Create handler with mock repository
+	logHandler := handler.NewLogHandler(logRepo)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/log/export", logHandler.ExportLog)
+
+	// Create logs for testing
+	start := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		logRepo.Create(&model.Log{
+			ID:            i + 1,
+			CreatedAt:     start.Add(time.Duration(i) * time.Hour),
+			Method:        "GET",
+			Route:         "/api/farm/",
+			Endpoint:      "GET /api/farm/",
+			UserAgent:     "Test Agent",
+			Subject:       "reporting",
+			StatusCode:    http.StatusOK,
+			LatencyMicros: 150,
+			ResponseSize:  42,
+			ClientIP:      "10.0.0.1",
+		})
+	}
+
+	// Perform the request
+	request, _ := http.NewRequest("GET", "/log/export?from=2023-07-01T01:00:00Z", nil)
+	request.Header.Set("Accept", "text/csv, application/x-ndjson;q=0.5")
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	expectedBody := "id,created_at,method,route,endpoint,user_agent,subject,status_code,latency_us,response_size,client_ip\n" +
+		"2,2023-07-01T01:00:00Z,GET,/api/farm/,GET /api/farm/,Test Agent,reporting,200,150,42,10.0.0.1\n" +
+		"3,2023-07-01T02:00:00Z,GET,/api/farm/,GET /api/farm/,Test Agent,reporting,200,150,42,10.0.0.1\n"
+	assert.Equal(t, expectedBody, responseRecorder.Body.String())
+
+	// Check the NDJSON keys match the CSV header
+	request, _ = http.NewRequest("GET", "/log/export?from=2023-07-01T02:00:00Z", nil)
+	request.Header.Set("Accept", "application/x-ndjson")
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &record))
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	assert.ElementsMatch(t, strings.Split(strings.SplitN(expectedBody, "\n", 2)[0], ","), keys)
+
+	// Check an invalid range is refused
+	request, _ = http.NewRequest("GET", "/log/export?from=yesterday", nil)
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+}
+
+func TestExport_NotAcceptable(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm/export", farmHandler.ExportFarm)
+
+	// Perform the request
+	request, _ := http.NewRequest("GET", "/farm/export", nil)
+	request.Header.Set("Accept", "application/xml")
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusNotAcceptable, responseRecorder.Code)
+}
+
+// failingResponseWriter fails every write of the response body
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *failingResponseWriter) Write(data []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("connection reset")
+}
+
+func TestExportFarm_HeaderWriteError(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm/export", farmHandler.ExportFarm)
+
+	// Create more farms than are written between flushes
+	for i := 1; i <= 150; i++ {
+		farmRepo.Create(&model.Farm{Name: fmt.Sprintf("Farm %d", i)})
+	}
+
+	// Perform the request
+	request, _ := http.NewRequest("GET", "/farm/export", nil)
+	request.Header.Set("Accept", "text/csv")
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+	responseWriter := &failingResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+	router.ServeHTTP(responseWriter, request)
+
+	// Check the export stopped at the header row
+	assert.Equal(t, 1, responseWriter.writes)
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, "Failed to export", hook.LastEntry().Message)
+		assert.Equal(t, 0, hook.LastEntry().Data["records"])
+	}
+}
+
+// failingExportFarmRepository fails its export once after farms were streamed
+type failingExportFarmRepository struct {
+	*repository.MockFarmRepository
+	after int
+}
+
+func (r *failingExportFarmRepository) InOrganization(organizationId int) gormRepository.FarmRepository {
+	return r
+}
+
+func (r *failingExportFarmRepository) AuditedBy(actor string) gormRepository.FarmRepository {
+	return r
+}
+
+func (r *failingExportFarmRepository) Export(each func(model.Farm) error) error {
+	streamed := 0
+	err := r.MockFarmRepository.Export(func(farm model.Farm) error {
+		if streamed == r.after {
+			return errors.New("connection lost")
+		}
+		streamed++
+		return each(farm)
+	})
+	if err == nil {
+		err = errors.New("connection lost")
+	}
+	return err
+}
+
+func TestExportFarm_RepositoryError(t *testing.T) {
+	for _, accept := range []string{"application/x-ndjson", "text/csv"} {
+		// Create mock repositories
+		farmRepo := &failingExportFarmRepository{MockFarmRepository: repository.NewMockFarmRepository()}
+		pondRepo := repository.NewMockPondRepository()
+		auditRepo := repository.NewMockAuditRepository()
+
+		// Create handler with mock repositories
+		farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+		// Create a Gin router and set up the handler route
+		router := gin.Default()
+		router.Use(withRole(model.RoleAdmin))
+		router.GET("/farm/export", farmHandler.ExportFarm)
+
+		// Create farms for testing
+		farmRepo.Create(&model.Farm{Name: "Farm 1"})
+		farmRepo.Create(&model.Farm{Name: "Farm 2"})
+
+		// Check a failure before the first farm responds 500
+		request, _ := http.NewRequest("GET", "/farm/export", nil)
+		request.Header.Set("Accept", accept)
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, http.StatusInternalServerError, responseRecorder.Code, accept)
+		assert.JSONEq(t, `{"code":500,"status":"error","message":"Failed to export farms"}`, responseRecorder.Body.String(), accept)
+
+		// Check a failure after the first farm marks the export incomplete
+		farmRepo.after = 1
+		responseRecorder = httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, http.StatusOK, responseRecorder.Code, accept)
+		assert.Equal(t, "Failed to export farms", responseRecorder.Result().Trailer.Get("X-Export-Error"), accept)
+		expectedBody := "id,name\n1,Farm 1\n"
+		if accept == "application/x-ndjson" {
+			expectedBody = `{"id":1,"name":"Farm 1"}` + "\n" +
+				`{"code":500,"message":"Failed to export farms","status":"error"}` + "\n"
+		}
+		assert.Equal(t, expectedBody, responseRecorder.Body.String(), accept)
+	}
+}
@@ -2,28 +2,31 @@ package test
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
-	"github.com/gin-gonic/gin"
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateFarm(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.POST("/farm", farmHandler.CreateFarm)
 
 	// Create a test request
@@ -44,14 +47,14 @@ func TestCreateFarm(t *testing.T) {
 		"code":    http.StatusOK,
 		"status":  "success",
 		"message": "New farm created successfully",
-		"data": map[string]interface {}{
+		"data": map[string]interface{}{
 			"id":   1,
 			"name": "Farm 1",
 		},
 	}
 	actualResponse := gin.H{}
 	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
-	
+
 	// Convert code to int if it exists
 	if codeFloat, ok := actualResponse["code"].(float64); ok {
 		code := int(codeFloat)
@@ -70,7 +73,7 @@ func TestCreateFarm(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the farm was created in the repository
-	farms, _ := farmRepo.Get()
+	farms := listFarms(t, farmRepo)
 	assert.Len(t, farms, 1)
 	assert.Equal(t, "Farm 1", farms[0].Name)
 }
@@ -78,13 +81,15 @@ func TestCreateFarm(t *testing.T) {
 func TestGetFarm(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.GET("/farm", farmHandler.GetFarm)
 
 	// Create a test request
@@ -104,6 +109,11 @@ func TestGetFarm(t *testing.T) {
 		"status":  "success",
 		"message": "Farm fetched successfully",
 		"data":    []interface{}{},
+		"pagination": map[string]interface{}{
+			"page":      float64(1),
+			"page_size": float64(20),
+			"total":     float64(0),
+		},
 	}
 	actualResponse := gin.H{}
 	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
@@ -113,7 +123,7 @@ func TestGetFarm(t *testing.T) {
 		code := int(codeFloat)
 		actualResponse["code"] = code
 	}
-	
+
 	assert.NoError(t, err)
 	assert.Equal(t, expectedResponse, actualResponse)
 }
@@ -121,13 +131,15 @@ func TestGetFarm(t *testing.T) {
 func TestGetFarmById(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.GET("/farm/:id", farmHandler.GetFarmById)
 
 	// Create a farm for testing
@@ -152,7 +164,7 @@ func TestGetFarmById(t *testing.T) {
 		"code":    http.StatusOK,
 		"status":  "success",
 		"message": "Farm fetched successfully",
-		"data": map[string]interface {}{
+		"data": map[string]interface{}{
 			"id":   1,
 			"name": "Farm 1",
 		},
@@ -181,13 +193,15 @@ func TestGetFarmById(t *testing.T) {
 func TestUpdateFarm(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.PUT("/farm/:id", farmHandler.UpdateFarm)
 
 	// Create a farm for testing
@@ -214,7 +228,7 @@ func TestUpdateFarm(t *testing.T) {
 		"code":    http.StatusOK,
 		"status":  "success",
 		"message": "Farm updated successfully",
-		"data": map[string]interface {}{
+		"data": map[string]interface{}{
 			"id":   1,
 			"name": "Updated Farm",
 		},
@@ -240,7 +254,7 @@ func TestUpdateFarm(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the farm was updated in the repository
-	farms, _ := farmRepo.Get()
+	farms := listFarms(t, farmRepo)
 	assert.Len(t, farms, 1)
 	assert.Equal(t, "Updated Farm", farms[0].Name)
 }
@@ -248,13 +262,15 @@ func TestUpdateFarm(t *testing.T) {
 func TestDeleteFarm(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
 
 	// Create a farm for testing
@@ -293,20 +309,22 @@ func TestDeleteFarm(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the farm was deleted from the repository
-	farms, _ := farmRepo.Get()
+	farms := listFarms(t, farmRepo)
 	assert.Len(t, farms, 0)
 }
 
 func TestCreateFarm_NameExists(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.POST("/farm", farmHandler.CreateFarm)
 
 	// Create a farm for testing
@@ -350,13 +368,15 @@ func TestCreateFarm_NameExists(t *testing.T) {
 func TestGetFarmById_InvalidParam(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.GET("/farm/:id", farmHandler.GetFarmById)
 
 	// Create a test request with an invalid ID param
@@ -384,7 +404,7 @@ func TestGetFarmById_InvalidParam(t *testing.T) {
 		code := int(codeFloat)
 		actualResponse["code"] = code
 	}
-	
+
 	assert.NoError(t, err)
 	assert.Equal(t, expectedResponse, actualResponse)
 }
@@ -392,18 +412,20 @@ func TestGetFarmById_InvalidParam(t *testing.T) {
 func TestUpdateFarm_NotFound(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.PUT("/farm/:id", farmHandler.UpdateFarm)
 
 	// Create a test request with a non-existing ID
 	requestBody := strings.NewReader(`{"name": "Updated Farm"}`)
-	request, _ := http.NewRequest("PUT", "/farm/1", requestBody)
+	request, _ := http.NewRequest("PUT", "/farm/42", requestBody)
 	request.Header.Set("User-Agent", "Test Agent")
 	request.Header.Set("Content-Type", "application/json")
 	responseRecorder := httptest.NewRecorder()
@@ -411,16 +433,17 @@ func TestUpdateFarm_NotFound(t *testing.T) {
 	// Perform the request
 	router.ServeHTTP(responseRecorder, request)
 
-	// Check the response status code
-	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	// Check the response status code and location
+	assert.Equal(t, http.StatusCreated, responseRecorder.Code)
+	assert.Equal(t, "/farm/42", responseRecorder.Header().Get("Location"))
 
 	// Check the response body
 	expectedResponse := gin.H{
-		"code":    http.StatusOK,
+		"code":    http.StatusCreated,
 		"status":  "success",
 		"message": "Data Not Found. New farm created successfully",
-		"data": map[string]interface {}{
-			"id":   1,
+		"data": map[string]interface{}{
+			"id":   42,
 			"name": "Updated Farm",
 		},
 	}
@@ -445,21 +468,74 @@ func TestUpdateFarm_NotFound(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the farm was created in the repository
-	farms, _ := farmRepo.Get()
+	farms := listFarms(t, farmRepo)
 	assert.Len(t, farms, 1)
+	assert.Equal(t, 42, farms[0].ID)
 	assert.Equal(t, "Updated Farm", farms[0].Name)
 }
 
+func TestUpdateFarm_NotFoundStrict(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, true)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.PUT("/farm/:id", farmHandler.UpdateFarm)
+
+	// Create a test request with a non-existing ID
+	requestBody := strings.NewReader(`{"name": "Updated Farm"}`)
+	request, _ := http.NewRequest("PUT", "/farm/42", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusNotFound, responseRecorder.Code)
+
+	// Check the response body
+	expectedResponse := gin.H{
+		"code":    http.StatusNotFound,
+		"status":  "error",
+		"message": "Data Not Found",
+	}
+	actualResponse := gin.H{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	// Convert code to int if it exists
+	if codeFloat, ok := actualResponse["code"].(float64); ok {
+		code := int(codeFloat)
+		actualResponse["code"] = code
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResponse, actualResponse)
+
+	// Check that no farm was created in the repository
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 0)
+}
+
 func TestDeleteFarm_NotFound(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
 
 	// Create a farm for testing
@@ -498,20 +574,22 @@ func TestDeleteFarm_NotFound(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the farm was not deleted from the repository
-	farms, _ := farmRepo.Get()
+	farms := listFarms(t, farmRepo)
 	assert.Len(t, farms, 1)
 }
 
 func TestCreateFarm_InvalidPayload(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.POST("/farm", farmHandler.CreateFarm)
 
 	// Create a test request with an invalid payload (missing name field)
@@ -549,13 +627,15 @@ func TestCreateFarm_InvalidPayload(t *testing.T) {
 func TestGetFarmById_NotFound(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.GET("/farm/:id", farmHandler.GetFarmById)
 
 	// Create a test request with a non-existing ID
@@ -591,13 +671,15 @@ func TestGetFarmById_NotFound(t *testing.T) {
 func TestUpdateFarm_InvalidPayload(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.PUT("/farm/:id", farmHandler.UpdateFarm)
 
 	// Create a test request with an invalid payload (missing name field)
@@ -635,13 +717,15 @@ func TestUpdateFarm_InvalidPayload(t *testing.T) {
 func TestDeleteFarm_InvalidParam(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
-	logRepo := repository.NewMockLogRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	farmHandler := handler.NewFarmHandler(farmRepo, logRepo)
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
 
 	// Create a test request with an invalid ID param
@@ -673,3 +757,762 @@ func TestDeleteFarm_InvalidParam(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedResponse, actualResponse)
 }
+
+func TestGetFarm_Pagination(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm", farmHandler.GetFarm)
+
+	// Create farms for testing
+	for _, name := range []string{"Farm 1", "Farm 2", "Farm 3", "Other"} {
+		farmRepo.Create(&model.Farm{Name: name})
+	}
+
+	// Create a test request
+	request, _ := http.NewRequest("GET", "/farm?name_contains=Farm&sort=-id&page_size=2", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Data       []model.Farm     `json:"data"`
+		Pagination model.Pagination `json:"pagination"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Farm{{ID: 3, Name: "Farm 3"}, {ID: 2, Name: "Farm 2"}}, actualResponse.Data)
+	assert.Equal(t, model.Pagination{
+		Page:     1,
+		PageSize: 2,
+		Total:    3,
+		Next:     "/farm?name_contains=Farm&page=2&page_size=2&sort=-id",
+	}, actualResponse.Pagination)
+}
+
+func TestGetFarm_InvalidQuery(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm", farmHandler.GetFarm)
+
+	for _, query := range []string{"page=0", "page_size=1000", "sort=unknown", "page=first"} {
+		// Create a test request with an invalid query
+		request, _ := http.NewRequest("GET", "/farm?"+query, nil)
+		request.Header.Set("User-Agent", "Test Agent")
+		responseRecorder := httptest.NewRecorder()
+
+		// Perform the request
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the response status code
+		assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	}
+}
+
+func TestDeleteFarm_HasPonds(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	farmRepo.LinkPondRepository(pondRepo)
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
+
+	// Create a farm and pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	// Create a test request
+	request, _ := http.NewRequest("DELETE", "/farm/1", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusConflict, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Message string       `json:"message"`
+		Data    []model.Pond `json:"data"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Farm still has ponds", actualResponse.Message)
+	assert.Equal(t, []model.Pond{{ID: 1, Name: "Pond 1", FarmID: 1}}, actualResponse.Data)
+
+	// Check if the farm and pond were not deleted from the repository
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 1)
+	ponds := listPonds(t, pondRepo)
+	assert.Len(t, ponds, 1)
+}
+
+func TestDeleteFarm_Cascade(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	farmRepo.LinkPondRepository(pondRepo)
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
+
+	// Create farms and ponds for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farmRepo.Create(&model.Farm{Name: "Farm 2"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+	pondRepo.Create(&model.Pond{Name: "Pond 2", FarmID: 2})
+
+	// Create a test request
+	request, _ := http.NewRequest("DELETE", "/farm/1?cascade=true", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check if the farm and its pond were deleted from the repository
+	farms := listFarms(t, farmRepo)
+	assert.Equal(t, []model.Farm{{ID: 2, Name: "Farm 2", Version: 1, OrganizationID: model.DefaultOrganizationID}}, farms)
+	ponds := listPonds(t, pondRepo)
+	assert.Equal(t, []model.Pond{{ID: 2, Name: "Pond 2", FarmID: 2, Version: 1, OrganizationID: model.DefaultOrganizationID}}, ponds)
+}
+
+func TestRestoreFarm(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm", farmHandler.GetFarm)
+	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
+	router.POST("/farm/:id/restore", farmHandler.RestoreFarm)
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+
+	// Perform the requests, restoring with the ETag of the deleted farm
+	for _, method := range []string{"DELETE", "POST"} {
+		path := "/farm/1"
+		if method == "POST" {
+			path += "/restore"
+		}
+		request, _ := http.NewRequest(method, path, nil)
+		request.Header.Set("User-Agent", "Test Agent")
+		if method == "POST" {
+			// Check a stale ETag is refused
+			request.Header.Set("If-Match", `"1"`)
+			responseRecorder := httptest.NewRecorder()
+			router.ServeHTTP(responseRecorder, request)
+			assert.Equal(t, http.StatusPreconditionFailed, responseRecorder.Code)
+			request.Header.Set("If-Match", `"2"`)
+		}
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	}
+
+	// Check the farm is listed again
+	farms := listFarms(t, farmRepo)
+	assert.Equal(t, []model.Farm{{ID: 1, Name: "Farm 1", Version: 3, OrganizationID: model.DefaultOrganizationID}}, farms)
+
+	// Restoring an active farm fails
+	request, _ := http.NewRequest("POST", "/farm/1/restore", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusNotFound, responseRecorder.Code)
+}
+
+func TestGetFarm_IncludeDeleted(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm", farmHandler.GetFarm)
+
+	// Create farms for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farmRepo.Create(&model.Farm{Name: "Farm 2"})
+	farm, _ := farmRepo.GetById(1)
+	farmRepo.Delete(farm)
+
+	for query, expectedCount := range map[string]int{"": 1, "?include_deleted=true": 2} {
+		// Create a test request
+		request, _ := http.NewRequest("GET", "/farm"+query, nil)
+		request.Header.Set("User-Agent", "Test Agent")
+		responseRecorder := httptest.NewRecorder()
+
+		// Perform the request
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the response
+		var actualResponse struct {
+			Data []model.Farm `json:"data"`
+		}
+		err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+		assert.Len(t, actualResponse.Data, expectedCount)
+	}
+}
+
+func TestCreateFarm_NameExistsInDeletedFarm(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm", farmHandler.CreateFarm)
+
+	// Create a deleted farm for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farm, _ := farmRepo.GetById(1)
+	farmRepo.Delete(farm)
+
+	// Create a test request
+	requestBody := strings.NewReader(`{"name": "Farm 1"}`)
+	request, _ := http.NewRequest("POST", "/farm", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusConflict, responseRecorder.Code)
+
+	// Check the response body
+	expectedResponse := gin.H{
+		"code":    float64(http.StatusConflict),
+		"status":  "error",
+		"message": "Farm name already exists in a deleted farm",
+	}
+	actualResponse := gin.H{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResponse, actualResponse)
+}
+
+func TestCreateFarm_IgnoresDeletedAt(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm", farmHandler.CreateFarm)
+	router.PUT("/farm/:id", farmHandler.UpdateFarm)
+
+	testCases := []struct {
+		method       string
+		path         string
+		name         string
+		id           int
+		expectedCode int
+	}{
+		{"POST", "/farm", "Farm 1", 1, http.StatusOK},
+		{"PUT", "/farm/2", "Farm 2", 2, http.StatusCreated},
+		{"PUT", "/farm/1", "Farm 3", 1, http.StatusOK},
+	}
+	for _, testCase := range testCases {
+		// Perform a request carrying a deleted_at
+		requestBody := strings.NewReader(`{"name": "` + testCase.name + `", "deleted_at": "2020-01-01T00:00:00Z"}`)
+		request, _ := http.NewRequest(testCase.method, testCase.path, requestBody)
+		request.Header.Set("Content-Type", "application/json")
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the farm is stored and returned without it
+		assert.Equal(t, testCase.expectedCode, responseRecorder.Code, testCase.method+" "+testCase.path)
+		assert.NotContains(t, responseRecorder.Body.String(), "2020-01-01", testCase.method+" "+testCase.path)
+		farm, err := farmRepo.GetById(testCase.id)
+		if assert.NoError(t, err, testCase.method+" "+testCase.path) {
+			assert.Nil(t, farm.DeletedAt, testCase.method+" "+testCase.path)
+		}
+	}
+}
+
+func TestGetFarmHistory(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	farmRepo.LinkAuditRepository(auditRepo)
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm", farmHandler.CreateFarm)
+	router.PUT("/farm/:id", farmHandler.UpdateFarm)
+	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
+	router.GET("/farm/:id/history", farmHandler.GetFarmHistory)
+
+	// Perform the mutations
+	mutations := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/farm", `{"name": "Farm 1"}`},
+		{"PUT", "/farm/1", `{"name": "Updated Farm"}`},
+		{"DELETE", "/farm/1", ``},
+	}
+	for _, mutation := range mutations {
+		request, _ := http.NewRequest(mutation.method, mutation.path, strings.NewReader(mutation.body))
+		request.Header.Set("User-Agent", "Test Agent")
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Actor", "Impostor")
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	}
+
+	// Create a test request
+	request, _ := http.NewRequest("GET", "/farm/1/history", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Data []model.Audit `json:"data"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Len(t, actualResponse.Data, 3)
+	for i, operation := range []string{model.AuditCreate, model.AuditUpdate, model.AuditDelete} {
+		assert.Equal(t, operation, actualResponse.Data[i].Operation)
+		assert.Equal(t, model.ApiKeyMember(1), actualResponse.Data[i].Actor)
+		assert.Equal(t, model.AuditEntityFarm, actualResponse.Data[i].Entity)
+	}
+	assert.JSONEq(t, `null`, string(actualResponse.Data[0].Before))
+	assert.JSONEq(t, `{"id": 1, "name": "Farm 1"}`, string(actualResponse.Data[0].After))
+	assert.JSONEq(t, `{"id": 1, "name": "Farm 1"}`, string(actualResponse.Data[1].Before))
+	assert.JSONEq(t, `{"id": 1, "name": "Updated Farm"}`, string(actualResponse.Data[1].After))
+	assert.JSONEq(t, `{"id": 1, "name": "Updated Farm"}`, string(actualResponse.Data[2].Before))
+
+	var deletedFarm model.Farm
+	assert.NoError(t, json.Unmarshal(actualResponse.Data[2].After, &deletedFarm))
+	assert.NotNil(t, deletedFarm.DeletedAt)
+}
+
+func TestCreateFarm_AuditError(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	farmRepo.LinkAuditRepository(auditRepo)
+	auditRepo.Fail(errors.New("audits unavailable"))
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm", farmHandler.CreateFarm)
+
+	// Perform the request
+	request, _ := http.NewRequest("POST", "/farm", strings.NewReader(`{"name": "Farm 1"}`))
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the farm was not created without its audit
+	assert.Equal(t, http.StatusInternalServerError, responseRecorder.Code)
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 0)
+}
+
+func TestPatchFarm_NotFound(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.PATCH("/farm/:id", farmHandler.PatchFarm)
+
+	// Create a test request with a non-existing ID
+	requestBody := strings.NewReader(`{"name": "Updated Farm"}`)
+	request, _ := http.NewRequest("PATCH", "/farm/1", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusNotFound, responseRecorder.Code)
+
+	// Check if no farm was created in the repository
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 0)
+}
+
+func TestGetFarmById_ETag(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm/:id", farmHandler.GetFarmById)
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+
+	// Check the ETag is returned
+	request, _ := http.NewRequest("GET", "/farm/1", nil)
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, `"1"`, responseRecorder.Header().Get("ETag"))
+
+	// Check a matching If-None-Match is not modified
+	request, _ = http.NewRequest("GET", "/farm/1", nil)
+	request.Header.Set("If-None-Match", `W/"1"`)
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusNotModified, responseRecorder.Code)
+	assert.Empty(t, responseRecorder.Body.String())
+
+	// Check a stale If-None-Match returns the farm
+	request, _ = http.NewRequest("GET", "/farm/1", nil)
+	request.Header.Set("If-None-Match", `"0"`)
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+}
+
+func TestUpdateFarm_IfMatch(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.PUT("/farm/:id", farmHandler.UpdateFarm)
+	router.PATCH("/farm/:id", farmHandler.PatchFarm)
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+
+	// Check the current version is updated
+	request, _ := http.NewRequest("PUT", "/farm/1", strings.NewReader(`{"name": "Farm 2"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", `"1"`)
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, `"2"`, responseRecorder.Header().Get("ETag"))
+
+	// Check a stale version is refused
+	for _, method := range []string{"PUT", "PATCH"} {
+		request, _ = http.NewRequest(method, "/farm/1", strings.NewReader(`{"name": "Farm 3"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("If-Match", `"1"`)
+		responseRecorder = httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, http.StatusPreconditionFailed, responseRecorder.Code)
+	}
+
+	// Check If-Match never matches a missing farm
+	request, _ = http.NewRequest("PUT", "/farm/2", strings.NewReader(`{"name": "Farm 3"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", "*")
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusPreconditionFailed, responseRecorder.Code)
+
+	farms := listFarms(t, farmRepo)
+	assert.Equal(t, []model.Farm{{ID: 1, Name: "Farm 2", Version: 2, OrganizationID: model.DefaultOrganizationID}}, farms)
+}
+
+func TestDeleteFarm_IfMatch(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+
+	// Check a stale version is refused, then the current one deletes
+	for _, test := range []struct {
+		tag  string
+		code int
+	}{
+		{`"2"`, http.StatusPreconditionFailed},
+		{`"0", "1"`, http.StatusOK},
+	} {
+		request, _ := http.NewRequest("DELETE", "/farm/1", nil)
+		request.Header.Set("If-Match", test.tag)
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, test.code, responseRecorder.Code)
+	}
+
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 0)
+}
+
+func TestBulkFarm(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	farmRepo.LinkAuditRepository(auditRepo)
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm/bulk", farmHandler.BulkFarm)
+	router.GET("/farm/:id", farmHandler.GetFarmById)
+
+	// Create farms for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farmRepo.Create(&model.Farm{Name: "Farm 2"})
+
+	// Create a test request
+	requestBody := strings.NewReader(`[
+		{"operation": "create", "name": "Farm 3"},
+		{"operation": "update", "id": 1, "name": "Farm 4"},
+		{"operation": "delete", "id": 2}
+	]`)
+	request, _ := http.NewRequest("POST", "/farm/bulk", requestBody)
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	var response struct {
+		Data []model.BulkResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 3)
+	for _, result := range response.Data {
+		assert.Equal(t, model.BulkSuccess, result.Status)
+	}
+
+	// Check the farms in the repository
+	updatedFarm, _ := farmRepo.GetById(1)
+	assert.Equal(t, &model.Farm{ID: 1, Name: "Farm 4", Version: 2, OrganizationID: model.DefaultOrganizationID}, updatedFarm)
+	deletedFarm, _ := farmRepo.GetById(2)
+	assert.Nil(t, deletedFarm)
+	createdFarm, _ := farmRepo.GetById(3)
+	assert.Equal(t, &model.Farm{ID: 3, Name: "Farm 3", Version: 1, OrganizationID: model.DefaultOrganizationID}, createdFarm)
+
+	// Check every item was audited
+	for id := 1; id <= 3; id++ {
+		audits, _ := auditRepo.GetByEntity(model.AuditEntityFarm, id)
+		assert.Len(t, audits, 1)
+	}
+}
+
+func TestBulkFarm_AtomicRejected(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	farmRepo.LinkAuditRepository(auditRepo)
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm/bulk", farmHandler.BulkFarm)
+
+	// Create a farm with a pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	// Create a test request
+	requestBody := strings.NewReader(`[
+		{"operation": "create", "name": "Farm 2"},
+		{"operation": "create", "name": "Farm 2"},
+		{"operation": "create", "name": "Farm 1"},
+		{"operation": "delete", "id": 1},
+		{"operation": "update", "id": 9, "name": "Farm 3"}
+	]`)
+	request, _ := http.NewRequest("POST", "/farm/bulk", requestBody)
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response
+	assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	var response struct {
+		Data []model.BulkResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+	expectedResults := []model.BulkResult{
+		{Index: 0, Operation: model.BulkCreate, Status: model.BulkSkipped},
+		{Index: 1, Operation: model.BulkCreate, Status: model.BulkError, Code: http.StatusConflict, Message: "Farm name is duplicated in the request"},
+		{Index: 2, Operation: model.BulkCreate, Status: model.BulkError, Code: http.StatusConflict, Message: "Farm name already exists"},
+		{Index: 3, Operation: model.BulkDelete, Status: model.BulkError, Code: http.StatusConflict, Message: "Farm still has ponds"},
+		{Index: 4, Operation: model.BulkUpdate, Status: model.BulkError, Code: http.StatusNotFound, Message: "Data Not Found"},
+	}
+	assert.Equal(t, expectedResults, response.Data)
+
+	// Check nothing was applied
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 1)
+	audits, _ := auditRepo.GetByEntity(model.AuditEntityFarm, 1)
+	assert.Len(t, audits, 0)
+}
+
+func TestBulkFarm_BestEffort(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm/bulk", farmHandler.BulkFarm)
+
+	// Create a test request
+	requestBody := strings.NewReader(`[
+		{"operation": "create", "name": "Farm 1"},
+		{"operation": "create", "name": ""},
+		{"operation": "create", "name": "Farm 2"}
+	]`)
+	request, _ := http.NewRequest("POST", "/farm/bulk?atomic=false", requestBody)
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response
+	assert.Equal(t, http.StatusMultiStatus, responseRecorder.Code)
+	var response struct {
+		Data []model.BulkResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+	assert.Equal(t, model.BulkSuccess, response.Data[0].Status)
+	assert.Equal(t, model.BulkError, response.Data[1].Status)
+	assert.Equal(t, model.BulkSuccess, response.Data[2].Status)
+
+	// Check the valid farms were created
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 2)
+}
@@ -0,0 +1,220 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/iotest"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+// newImportRequest uploads content as the file of an import request
+func newImportRequest(t *testing.T, path string, fileName string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", fileName)
+	assert.NoError(t, err)
+	part.Write(content)
+	assert.NoError(t, writer.Close())
+
+	request, _ := http.NewRequest("POST", path, body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func newImportRouter() (*gin.Engine, *repository.MockFarmRepository, *repository.MockPondRepository) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	farmRepo.LinkPondRepository(pondRepo)
+
+	// Create handler with mock repositories
+	importHandler := handler.NewImportHandler(farmRepo, pondRepo)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/import", importHandler.ImportFarmPond)
+	return router, farmRepo, pondRepo
+}
+
+func TestImportFarmPond_CSV(t *testing.T) {
+	router, farmRepo, pondRepo := newImportRouter()
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+
+	// Perform the request
+	content := "Farm,Pond\nFarm 1,Pond 1\nFarm 2,\n\nFarm 2,Pond 2\nFarm 3,Pond 3\n"
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, newImportRequest(t, "/import", "inventory.csv", []byte(content)))
+
+	// Check the response
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	var response struct {
+		Data []model.ImportResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+	expectedResults := []model.ImportResult{
+		{Row: 2, Farm: "Farm 1", Pond: "Pond 1", Status: model.BulkSuccess},
+		{Row: 3, Farm: "Farm 2", Status: model.BulkSuccess},
+		{Row: 5, Farm: "Farm 2", Pond: "Pond 2", Status: model.BulkSuccess},
+		{Row: 6, Farm: "Farm 3", Pond: "Pond 3", Status: model.BulkSuccess},
+	}
+	assert.Equal(t, expectedResults, response.Data)
+
+	// Check the ponds were resolved to their farms by name
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 3)
+	for farmId, pondName := range map[int]string{1: "Pond 1", 2: "Pond 2", 3: "Pond 3"} {
+		ponds, _ := pondRepo.GetByFarmId(farmId)
+		assert.Len(t, ponds, 1)
+		assert.Equal(t, pondName, ponds[0].Name)
+	}
+}
+
+func TestImportFarmPond_ByteOrderMark(t *testing.T) {
+	router, farmRepo, pondRepo := newImportRouter()
+
+	// Perform the request with a file saved by Excel as CSV UTF-8
+	content := "\ufeffFarm,Pond\nFarm 1,Pond 1\n"
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, newImportRequest(t, "/import", "inventory.csv", []byte(content)))
+
+	// Check the farm and pond were created
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	farm, err := farmRepo.GetByName("Farm 1")
+	if assert.NoError(t, err) {
+		ponds, _ := pondRepo.GetByFarmId(farm.ID)
+		assert.Len(t, ponds, 1)
+	}
+}
+
+func TestImportFarmPond_XLSX(t *testing.T) {
+	router, farmRepo, pondRepo := newImportRouter()
+
+	// Create a spreadsheet for testing
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	file.SetSheetRow(sheet, "A1", &[]string{"Pond", "Farm"})
+	file.SetSheetRow(sheet, "A2", &[]string{"Pond 1", "Farm 1"})
+	file.SetSheetRow(sheet, "A3", &[]string{"Pond 2", "Farm 1"})
+	content, err := file.WriteToBuffer()
+	assert.NoError(t, err)
+
+	// Perform the request
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, newImportRequest(t, "/import", "inventory.xlsx", content.Bytes()))
+
+	// Check the farm and ponds were created
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	farms := listFarms(t, farmRepo)
+	assert.Equal(t, []model.Farm{{ID: 1, Name: "Farm 1", Version: 1, OrganizationID: model.DefaultOrganizationID}}, farms)
+	ponds, _ := pondRepo.GetByFarmId(1)
+	assert.Len(t, ponds, 2)
+}
+
+func TestImportFarmPond_DryRun(t *testing.T) {
+	router, farmRepo, pondRepo := newImportRouter()
+
+	// Perform the request
+	content := "farm,pond\nFarm 1,Pond 1\n"
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, newImportRequest(t, "/import?dry_run=true", "inventory.csv", []byte(content)))
+
+	// Check nothing was committed
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Contains(t, responseRecorder.Body.String(), "Import validated, nothing was committed")
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 0)
+	ponds := listPonds(t, pondRepo)
+	assert.Len(t, ponds, 0)
+}
+
+func TestImportFarmPond_RowErrors(t *testing.T) {
+	router, farmRepo, pondRepo := newImportRouter()
+
+	// Create farms and ponds for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farmRepo.Create(&model.Farm{Name: "Farm 2"})
+	farmRepo.Delete(&model.Farm{ID: 2, Version: 1})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	// Perform the request
+	content := "farm,pond\nFarm 3,Pond 2\nFarm 1,\nFarm 2,Pond 3\nFarm 3,Pond 2\nFarm 3,Pond 1\n,Pond 4\n"
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, newImportRequest(t, "/import", "inventory.csv", []byte(content)))
+
+	// Check the response
+	assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	var response struct {
+		Data []model.ImportResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+	expectedResults := []model.ImportResult{
+		{Row: 2, Farm: "Farm 3", Pond: "Pond 2", Status: model.BulkSkipped},
+		{Row: 3, Farm: "Farm 1", Status: model.BulkError, Message: "Farm name already exists"},
+		{Row: 4, Farm: "Farm 2", Pond: "Pond 3", Status: model.BulkError, Message: "Farm name already exists in a deleted farm"},
+		{Row: 5, Farm: "Farm 3", Pond: "Pond 2", Status: model.BulkError, Message: "Pond name is duplicated in the file"},
+		{Row: 6, Farm: "Farm 3", Pond: "Pond 1", Status: model.BulkError, Message: "Pond name already exists"},
+		{Row: 7, Pond: "Pond 4", Status: model.BulkError, Message: "Invalid request payload"},
+	}
+	assert.Equal(t, expectedResults, response.Data)
+
+	// Check nothing was committed
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 1)
+	ponds := listPonds(t, pondRepo)
+	assert.Len(t, ponds, 1)
+}
+
+func TestImportFarmPond_InvalidFile(t *testing.T) {
+	router, _, _ := newImportRouter()
+
+	for fileName, content := range map[string]string{
+		"inventory.csv": "name,pond\nFarm 1,Pond 1\n",
+		"inventory.txt": "farm,pond\nFarm 1,Pond 1\n",
+		"empty.csv":     "farm,pond\n",
+	} {
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, newImportRequest(t, "/import", fileName, []byte(content)))
+		assert.Equal(t, http.StatusBadRequest, responseRecorder.Code, fileName)
+	}
+}
+
+func TestImportFarmPond_TooLarge(t *testing.T) {
+	router, _, _ := newImportRouter()
+
+	// Perform the request
+	content := append([]byte("farm,pond\n"), bytes.Repeat([]byte("Farm 1,Pond 1\n"), model.MaxImportSize/14+1)...)
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, newImportRequest(t, "/import", "inventory.csv", content))
+
+	// Check the response
+	assert.Equal(t, http.StatusRequestEntityTooLarge, responseRecorder.Code)
+}
+
+func TestParseImportRows_StopsAtMaxRows(t *testing.T) {
+	// Rows past the cap are followed by a failing read, never reached
+	var content bytes.Buffer
+	content.WriteString("farm,pond\n")
+	for i := 0; i < model.MaxImportRows+100; i++ {
+		fmt.Fprintf(&content, "Farm %d,\n", i)
+	}
+	reader := io.MultiReader(&content, iotest.ErrReader(errors.New("read past the row cap")))
+
+	_, err := handler.ParseImportRows(reader, "inventory.csv")
+	assert.ErrorIs(t, err, handler.ErrImportTooLarge)
+}
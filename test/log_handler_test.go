@@ -0,0 +1,103 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogRequest(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	logRepo := repository.NewMockLogRepository()
+
+	// Create handlers with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+	logHandler := handler.NewLogHandler(logRepo)
+
+	// Create a Gin router and set up the middleware and handler routes
+	router := gin.Default()
+	router.Use(logHandler.LogRequest)
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm", farmHandler.CreateFarm)
+	router.GET("/farm/:id", farmHandler.GetFarmById)
+
+	// Perform the requests
+	requestBody := strings.NewReader(`{"name": "Farm 1"}`)
+	request, _ := http.NewRequest("POST", "/farm", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/json")
+	request.RemoteAddr = "10.0.0.1:12345"
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	for _, path := range []string{"/farm/1", "/farm/2", "/unknown"} {
+		request, _ := http.NewRequest("GET", path, nil)
+		request.Header.Set("User-Agent", "Test Agent")
+		router.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	// Check the logs are recorded by route template
+	statistics, _ := logRepo.GetStatistics()
+	assert.Len(t, statistics, 2)
+	assert.Equal(t, int64(1), statistics["POST /farm"].Count)
+	assert.Equal(t, int64(2), statistics["GET /farm/:id"].Count)
+	assert.Equal(t, int64(1), statistics["GET /farm/:id"].UniqueUserAgent)
+
+	// Check the request details are recorded
+	logs := logRepo.Logs()
+	assert.Len(t, logs, 3)
+	assert.Equal(t, "POST", logs[0].Method)
+	assert.Equal(t, "/farm", logs[0].Route)
+	assert.Equal(t, http.StatusOK, logs[0].StatusCode)
+	assert.Greater(t, logs[0].ResponseSize, 0)
+	assert.Equal(t, "10.0.0.1", logs[0].ClientIP)
+	assert.False(t, logs[0].CreatedAt.IsZero())
+	assert.Equal(t, http.StatusOK, logs[1].StatusCode)
+	assert.Equal(t, http.StatusNotFound, logs[2].StatusCode)
+}
+
+func TestLogRequest_CreateLogFailed(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	logRepo := repository.NewMockLogRepository()
+	logRepo.SetCreateError(errors.New("Database unavailable"))
+
+	// Create handlers with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+	logHandler := handler.NewLogHandler(logRepo)
+
+	// Create a Gin router and set up the middleware and handler route
+	router := gin.Default()
+	router.Use(logHandler.LogRequest)
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm", farmHandler.CreateFarm)
+
+	// Create a test request
+	requestBody := strings.NewReader(`{"name": "Farm 1"}`)
+	request, _ := http.NewRequest("POST", "/farm", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the request still succeeds
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check if the farm was created in the repository
+	farms := listFarms(t, farmRepo)
+	assert.Len(t, farms, 1)
+}
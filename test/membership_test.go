@@ -0,0 +1,170 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withMember stands in for RequireScope, giving every request role in the
+// default organization and member as its farm member subject
+func withMember(role string, member string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(handler.ContextMember, member)
+		c.Set(handler.ContextRole, role)
+		c.Set(handler.ContextOrganization, model.DefaultOrganizationID)
+		c.Next()
+	}
+}
+
+func TestFarmMembership(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	farmMemberRepo := repository.NewMockFarmMemberRepository()
+	farmRepo.LinkPondRepository(pondRepo)
+	farmRepo.LinkFarmMemberRepository(farmMemberRepo)
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handlers with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+	importHandler := handler.NewImportHandler(farmRepo, pondRepo)
+	membershipHandler := handler.NewMembershipHandler(farmRepo, farmMemberRepo)
+
+	// Create a Gin router per member over the same repositories, matching
+	// escaped path params as the app does
+	admin, operator, viewer := model.ApiKeyMember(1), model.TokenMember(testIssuerSecret, "user-1"), model.ApiKeyMember(2)
+	routers := make(map[string]*gin.Engine)
+	for member, role := range map[string]string{admin: model.RoleAdmin, operator: model.RoleOperator, viewer: model.RoleViewer} {
+		router := gin.Default()
+		router.UseRawPath = true
+		router.Use(withMember(role, member))
+		router.POST("/farm/", farmHandler.CreateFarm)
+		router.GET("/farm/", farmHandler.GetFarm)
+		router.GET("/farm/:id", farmHandler.GetFarmById)
+		router.PUT("/farm/:id", farmHandler.UpdateFarm)
+		router.GET("/farm/:id/ponds", pondHandler.GetFarmPond)
+		router.POST("/pond/", pondHandler.CreatePond)
+		router.GET("/pond/", pondHandler.GetPond)
+		router.GET("/pond/:id", pondHandler.GetPondById)
+		router.POST("/import", importHandler.ImportFarmPond)
+		router.GET("/farm/:id/members", membershipHandler.GetFarmMember)
+		router.POST("/farm/:id/members", membershipHandler.CreateFarmMember)
+		router.DELETE("/farm/:id/members/:subject", membershipHandler.DeleteFarmMember)
+		routers[member] = router
+	}
+	serve := func(member string, method string, path string, body string) *httptest.ResponseRecorder {
+		var request *http.Request
+		if path == "/import" {
+			request = newImportRequest(t, path, "inventory.csv", []byte(body))
+		} else {
+			request, _ = http.NewRequest(method, path, strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+		}
+		responseRecorder := httptest.NewRecorder()
+		routers[member].ServeHTTP(responseRecorder, request)
+		return responseRecorder
+	}
+
+	// Create two farms with a pond each as the admin, who reaches every farm
+	// without being a member
+	require.Equal(t, http.StatusOK, serve(admin, "POST", "/farm/", `{"name": "Farm 1"}`).Code)
+	require.Equal(t, http.StatusOK, serve(admin, "POST", "/farm/", `{"name": "Farm 2"}`).Code)
+	require.Equal(t, http.StatusOK, serve(admin, "POST", "/pond/", `{"name": "Pond 1", "farm_id": 1}`).Code)
+	require.Equal(t, http.StatusOK, serve(admin, "POST", "/pond/", `{"name": "Pond 2", "farm_id": 2}`).Code)
+	members, _ := farmMemberRepo.GetByFarmId(1)
+	assert.Empty(t, members)
+
+	// Grant the operator the first farm
+	testCases := []struct {
+		name            string
+		member          string
+		method          string
+		path            string
+		body            string
+		expectedCode    int
+		expectedMessage string
+	}{
+		{"Grant member", admin, "POST", "/farm/1/members", `{"subject": "` + operator + `"}`, http.StatusOK, "Farm member granted successfully"},
+		{"Grant member twice", admin, "POST", "/farm/1/members", `{"subject": "` + operator + `"}`, http.StatusConflict, "Subject is already a member of the farm"},
+		{"Grant without subject", admin, "POST", "/farm/1/members", `{}`, http.StatusBadRequest, "Invalid request payload"},
+		{"Grant a bare name", admin, "POST", "/farm/1/members", `{"subject": "user-1"}`, http.StatusBadRequest, "Invalid request payload"},
+		{"Grant a key name", admin, "POST", "/farm/1/members", `{"subject": "apikey:Writer"}`, http.StatusBadRequest, "Invalid request payload"},
+		{"Grant without issuer", admin, "POST", "/farm/1/members", `{"subject": "|user-1"}`, http.StatusBadRequest, "Invalid request payload"},
+		{"Grant on missing farm", admin, "POST", "/farm/9/members", `{"subject": "` + operator + `"}`, http.StatusNotFound, "Data Not Found"},
+		{"Grant on invalid farm", admin, "POST", "/farm/one/members", `{"subject": "` + operator + `"}`, http.StatusBadRequest, "Invalid request param"},
+		{"Grant as operator", operator, "POST", "/farm/2/members", `{"subject": "` + operator + `"}`, http.StatusForbidden, "Role operator is not allowed to create membership"},
+		{"List members as viewer", viewer, "GET", "/farm/1/members", "", http.StatusForbidden, "Role viewer is not allowed to read membership"},
+		{"Revoke missing member", admin, "DELETE", "/farm/1/members/" + url.PathEscape(viewer), "", http.StatusNotFound, "Member Not Found"},
+	}
+	for _, testCase := range testCases {
+		responseRecorder := serve(testCase.member, testCase.method, testCase.path, testCase.body)
+		assert.Equal(t, testCase.expectedCode, responseRecorder.Code, testCase.name)
+		assert.Contains(t, responseRecorder.Body.String(), testCase.expectedMessage, testCase.name)
+	}
+	responseRecorder := serve(admin, "GET", "/farm/1/members", "")
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Contains(t, responseRecorder.Body.String(), `"farm_id":1,"subject":"`+operator+`"`)
+
+	// Check the operator lists only the first farm and its pond
+	for path, expected := range map[string]string{"/farm/": "Farm 1", "/pond/": "Pond 1"} {
+		responseRecorder := serve(operator, "GET", path, "")
+		assert.Equal(t, http.StatusOK, responseRecorder.Code, path)
+		assert.Contains(t, responseRecorder.Body.String(), expected, path)
+		assert.NotContains(t, responseRecorder.Body.String(), "Farm 2", path)
+		assert.NotContains(t, responseRecorder.Body.String(), "Pond 2", path)
+	}
+	assert.Equal(t, http.StatusOK, serve(operator, "GET", "/farm/1", "").Code)
+
+	// Check the operator cannot reach the second farm and its pond
+	for _, testCase := range []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/farm/2", ""},
+		{"PUT", "/farm/2", `{"name": "Farm 9"}`},
+		{"GET", "/farm/2/ponds", ""},
+		{"POST", "/pond/", `{"name": "Pond 9", "farm_id": 2}`},
+		{"GET", "/pond/2", ""},
+	} {
+		responseRecorder := serve(operator, testCase.method, testCase.path, testCase.body)
+		assert.Equal(t, http.StatusNotFound, responseRecorder.Code, testCase.method+" "+testCase.path)
+	}
+	responseRecorder = serve(operator, "POST", "/import", "Farm,Pond\nFarm 2,Pond 9\n")
+	assert.Contains(t, responseRecorder.Body.String(), "Farm Data Not Found")
+	_, err := pondRepo.GetByName("Pond 9")
+	assert.Error(t, err)
+
+	// Check the viewer reaches nothing
+	for _, path := range []string{"/farm/", "/pond/"} {
+		responseRecorder := serve(viewer, "GET", path, "")
+		assert.Equal(t, http.StatusOK, responseRecorder.Code, path)
+		assert.Contains(t, responseRecorder.Body.String(), `"data":[]`, path)
+		assert.Contains(t, responseRecorder.Body.String(), `"total":0`, path)
+	}
+
+	// Check the operator is granted the farms it creates
+	require.Equal(t, http.StatusOK, serve(operator, "POST", "/farm/", `{"name": "Farm 3"}`).Code)
+	assert.Equal(t, http.StatusOK, serve(operator, "GET", "/farm/3", "").Code)
+	member, err := farmMemberRepo.Get(3, operator)
+	require.NoError(t, err)
+	assert.Equal(t, operator, member.Subject)
+
+	// Revoke the first farm and check the operator no longer reaches it
+	responseRecorder = serve(admin, "DELETE", "/farm/1/members/"+url.PathEscape(operator), "")
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Contains(t, responseRecorder.Body.String(), "Farm member revoked successfully")
+	assert.Equal(t, http.StatusNotFound, serve(operator, "GET", "/farm/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(operator, "GET", "/pond/1", "").Code)
+}
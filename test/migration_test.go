@@ -0,0 +1,28 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	for _, dialect := range []string{database.DriverMySQL, database.DriverPostgres, database.DriverSQLite} {
+		migrations, err := database.Migrations(dialect)
+		assert.NoError(t, err, dialect)
+		assert.NotEmpty(t, migrations, dialect)
+
+		// Check versions are contiguous and every migration can be rolled back
+		for i, migration := range migrations {
+			assert.Equal(t, i+1, migration.Version, dialect)
+			assert.NotEmpty(t, migration.Name, dialect)
+			assert.NotEmpty(t, migration.Up, dialect)
+			assert.NotEmpty(t, migration.Down, dialect)
+		}
+	}
+
+	// Check an unknown dialect is refused
+	_, err := database.Migrations("oracle")
+	assert.Error(t, err)
+}
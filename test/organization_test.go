@@ -0,0 +1,175 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withOrganization stands in for RequireScope, giving every request the admin
+// role in organizationId
+func withOrganization(organizationId int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(handler.ContextRole, model.RoleAdmin)
+		c.Set(handler.ContextOrganization, organizationId)
+		c.Next()
+	}
+}
+
+func TestOrganizationIsolation(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	farmRepo.LinkPondRepository(pondRepo)
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handlers with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+	importHandler := handler.NewImportHandler(farmRepo, pondRepo)
+
+	// Create a Gin router per organization over the same repositories
+	routers := make(map[int]*gin.Engine)
+	for _, organizationId := range []int{1, 2} {
+		router := gin.Default()
+		router.Use(withOrganization(organizationId))
+		router.POST("/farm/", farmHandler.CreateFarm)
+		router.GET("/farm/", farmHandler.GetFarm)
+		router.GET("/farm/export", farmHandler.ExportFarm)
+		router.GET("/farm/:id", farmHandler.GetFarmById)
+		router.PUT("/farm/:id", farmHandler.UpdateFarm)
+		router.DELETE("/farm/:id", farmHandler.DeleteFarm)
+		router.POST("/farm/:id/restore", farmHandler.RestoreFarm)
+		router.GET("/farm/:id/history", farmHandler.GetFarmHistory)
+		router.GET("/farm/:id/ponds", pondHandler.GetFarmPond)
+		router.POST("/pond/", pondHandler.CreatePond)
+		router.GET("/pond/", pondHandler.GetPond)
+		router.GET("/pond/:id", pondHandler.GetPondById)
+		router.PUT("/pond/:id", pondHandler.UpdatePond)
+		router.DELETE("/pond/:id", pondHandler.DeletePond)
+		router.GET("/pond/:id/history", pondHandler.GetPondHistory)
+		router.POST("/import", importHandler.ImportFarmPond)
+		routers[organizationId] = router
+	}
+	serve := func(organizationId int, method string, path string, body string) *httptest.ResponseRecorder {
+		var request *http.Request
+		if path == "/import" {
+			request = newImportRequest(t, path, "inventory.csv", []byte(body))
+		} else {
+			request, _ = http.NewRequest(method, path, strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+		}
+		responseRecorder := httptest.NewRecorder()
+		routers[organizationId].ServeHTTP(responseRecorder, request)
+		return responseRecorder
+	}
+
+	// Create a farm and a pond in the first organization
+	require.Equal(t, http.StatusOK, serve(1, "POST", "/farm/", `{"name": "Farm 1"}`).Code)
+	require.Equal(t, http.StatusOK, serve(1, "POST", "/pond/", `{"name": "Pond 1", "farm_id": 1}`).Code)
+
+	// Check the second organization cannot reach them by id
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/farm/1", ""},
+		{"PUT", "/farm/1", `{"name": "Farm 2"}`},
+		{"DELETE", "/farm/1", ""},
+		{"GET", "/farm/1/history", ""},
+		{"GET", "/farm/1/ponds", ""},
+		{"POST", "/pond/", `{"name": "Pond 2", "farm_id": 1}`},
+		{"GET", "/pond/1", ""},
+		{"PUT", "/pond/1", `{"name": "Pond 2", "farm_id": 1}`},
+		{"DELETE", "/pond/1", ""},
+		{"GET", "/pond/1/history", ""},
+	}
+	for _, testCase := range testCases {
+		responseRecorder := serve(2, testCase.method, testCase.path, testCase.body)
+		assert.Equal(t, http.StatusNotFound, responseRecorder.Code, testCase.method+" "+testCase.path)
+	}
+
+	// Check the second organization lists nothing and may reuse the names
+	for _, path := range []string{"/farm/", "/pond/", "/farm/export"} {
+		responseRecorder := serve(2, "GET", path, "")
+		assert.Equal(t, http.StatusOK, responseRecorder.Code, path)
+		assert.NotContains(t, responseRecorder.Body.String(), "Farm 1", path)
+		assert.NotContains(t, responseRecorder.Body.String(), "Pond 1", path)
+	}
+	assert.Equal(t, http.StatusOK, serve(2, "POST", "/farm/", `{"name": "Farm 1"}`).Code)
+	assert.Equal(t, http.StatusOK, serve(2, "POST", "/import", "Farm,Pond\nFarm 1,Pond 1\n").Code)
+	assert.Equal(t, http.StatusConflict, serve(1, "POST", "/farm/", `{"name": "Farm 1"}`).Code)
+
+	// Check the first organization still has its farm and pond untouched
+	farm, err := farmRepo.GetById(1)
+	require.NoError(t, err)
+	assert.Equal(t, "Farm 1", farm.Name)
+	assert.Equal(t, 1, farm.Version)
+	pond, err := pondRepo.GetById(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pond.FarmID)
+	assert.Equal(t, 1, pond.Version)
+	ponds := listPonds(t, pondRepo.InOrganization(2))
+	require.Len(t, ponds, 1)
+	assert.Equal(t, 2, ponds[0].FarmID)
+}
+
+func TestOrganizationIsolation_Logs(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	logRepo := repository.NewMockLogRepository()
+
+	// Create handlers with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+	logHandler := handler.NewLogHandler(logRepo)
+	statisticsHandler := handler.NewStatisticsHandler(logRepo)
+
+	// Create a Gin router per organization over the same repositories
+	routers := make(map[int]*gin.Engine)
+	for _, organizationId := range []int{1, 2} {
+		router := gin.Default()
+		router.Use(logHandler.LogRequest)
+		router.Use(withOrganization(organizationId))
+		router.GET("/farm/", farmHandler.GetFarm)
+		router.GET("/statistics", statisticsHandler.GetStatistics)
+		router.GET("/log/export", logHandler.ExportLog)
+		routers[organizationId] = router
+	}
+	serve := func(organizationId int, path string) *httptest.ResponseRecorder {
+		request, _ := http.NewRequest("GET", path, nil)
+		responseRecorder := httptest.NewRecorder()
+		routers[organizationId].ServeHTTP(responseRecorder, request)
+		return responseRecorder
+	}
+
+	// List the farms twice in the first organization
+	require.Equal(t, http.StatusOK, serve(1, "/farm/").Code)
+	require.Equal(t, http.StatusOK, serve(1, "/farm/").Code)
+
+	// Check the second organization sees none of its logs
+	for _, path := range []string{"/statistics", "/statistics?bucket=hour", "/log/export"} {
+		responseRecorder := serve(2, path)
+		assert.Equal(t, http.StatusOK, responseRecorder.Code, path)
+		assert.NotContains(t, responseRecorder.Body.String(), "GET /farm/", path)
+	}
+
+	// Check the first organization sees them
+	responseRecorder := serve(1, "/statistics")
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Contains(t, responseRecorder.Body.String(), `"GET /farm/":{"count":2`)
+	for _, log := range logRepo.Logs()[:2] {
+		require.NotNil(t, log.OrganizationID)
+		assert.Equal(t, 1, *log.OrganizationID)
+	}
+}
@@ -7,10 +7,10 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/gin-gonic/gin"
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,13 +18,14 @@ func TestCreatePond(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.POST("/pond", pondHandler.CreatePond)
 
 	// Create a farm for testing
@@ -51,15 +52,15 @@ func TestCreatePond(t *testing.T) {
 		"code":    http.StatusOK,
 		"status":  "success",
 		"message": "New pond created successfully",
-		"data": map[string]interface {}{
-			"id":   1,
-			"name": "Pond 1",
+		"data": map[string]interface{}{
+			"id":      1,
+			"name":    "Pond 1",
 			"farm_id": 1,
 		},
 	}
 	actualResponse := gin.H{}
 	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
-	
+
 	// Convert code to int if it exists
 	if codeFloat, ok := actualResponse["code"].(float64); ok {
 		code := int(codeFloat)
@@ -82,7 +83,7 @@ func TestCreatePond(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the pond was created in the repository
-	ponds, _ := pondRepo.Get()
+	ponds := listPonds(t, pondRepo)
 	assert.Len(t, ponds, 1)
 	assert.Equal(t, "Pond 1", ponds[0].Name)
 }
@@ -91,13 +92,14 @@ func TestGetPond(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.GET("/pond", pondHandler.GetPond)
 
 	// Create a test request
@@ -117,6 +119,11 @@ func TestGetPond(t *testing.T) {
 		"status":  "success",
 		"message": "Pond fetched successfully",
 		"data":    []interface{}{},
+		"pagination": map[string]interface{}{
+			"page":      float64(1),
+			"page_size": float64(20),
+			"total":     float64(0),
+		},
 	}
 	actualResponse := gin.H{}
 	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
@@ -126,7 +133,7 @@ func TestGetPond(t *testing.T) {
 		code := int(codeFloat)
 		actualResponse["code"] = code
 	}
-	
+
 	assert.NoError(t, err)
 	assert.Equal(t, expectedResponse, actualResponse)
 }
@@ -135,13 +142,14 @@ func TestGetPondById(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.GET("/pond/:id", pondHandler.GetPondById)
 
 	// Create a farm for testing
@@ -152,8 +160,8 @@ func TestGetPondById(t *testing.T) {
 
 	// Create a pond for testing
 	pondRepo.Create(&model.Pond{
-		ID:   1,
-		Name: "Pond 1",
+		ID:     1,
+		Name:   "Pond 1",
 		FarmID: 1,
 	})
 
@@ -173,9 +181,9 @@ func TestGetPondById(t *testing.T) {
 		"code":    http.StatusOK,
 		"status":  "success",
 		"message": "Pond fetched successfully",
-		"data": map[string]interface {}{
-			"id":   1,
-			"name": "Pond 1",
+		"data": map[string]interface{}{
+			"id":      1,
+			"name":    "Pond 1",
 			"farm_id": 1,
 		},
 	}
@@ -208,13 +216,14 @@ func TestUpdatePond(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.PUT("/pond/:id", pondHandler.UpdatePond)
 
 	// Create a farm for testing
@@ -225,8 +234,8 @@ func TestUpdatePond(t *testing.T) {
 
 	// Create a pond for testing
 	pondRepo.Create(&model.Pond{
-		ID:   1,
-		Name: "Pond 1",
+		ID:     1,
+		Name:   "Pond 1",
 		FarmID: 1,
 	})
 
@@ -248,9 +257,9 @@ func TestUpdatePond(t *testing.T) {
 		"code":    http.StatusOK,
 		"status":  "success",
 		"message": "Pond updated successfully",
-		"data": map[string]interface {}{
-			"id":   1,
-			"name": "Updated Pond",
+		"data": map[string]interface{}{
+			"id":      1,
+			"name":    "Updated Pond",
 			"farm_id": 1,
 		},
 	}
@@ -279,7 +288,7 @@ func TestUpdatePond(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the pond was updated in the repository
-	ponds, _ := pondRepo.Get()
+	ponds := listPonds(t, pondRepo)
 	assert.Len(t, ponds, 1)
 	assert.Equal(t, "Updated Pond", ponds[0].Name)
 }
@@ -288,13 +297,14 @@ func TestDeletePond(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.DELETE("/pond/:id", pondHandler.DeletePond)
 
 	// Create a farm for testing
@@ -305,8 +315,8 @@ func TestDeletePond(t *testing.T) {
 
 	// Create a pond for testing
 	pondRepo.Create(&model.Pond{
-		ID:   1,
-		Name: "Pond 1",
+		ID:     1,
+		Name:   "Pond 1",
 		FarmID: 1,
 	})
 
@@ -340,7 +350,7 @@ func TestDeletePond(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the pond was deleted from the repository
-	ponds, _ := pondRepo.Get()
+	ponds := listPonds(t, pondRepo)
 	assert.Len(t, ponds, 0)
 }
 
@@ -348,13 +358,14 @@ func TestCreatePond_NameExists(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.POST("/pond", pondHandler.CreatePond)
 
 	// Create a farm for testing
@@ -365,8 +376,8 @@ func TestCreatePond_NameExists(t *testing.T) {
 
 	// Create a pond for testing
 	pondRepo.Create(&model.Pond{
-		ID:   1,
-		Name: "Pond 1",
+		ID:     1,
+		Name:   "Pond 1",
 		FarmID: 1,
 	})
 
@@ -406,13 +417,14 @@ func TestGetPondById_InvalidParam(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.GET("/pond/:id", pondHandler.GetPondById)
 
 	// Create a test request with an invalid ID param
@@ -440,7 +452,7 @@ func TestGetPondById_InvalidParam(t *testing.T) {
 		code := int(codeFloat)
 		actualResponse["code"] = code
 	}
-	
+
 	assert.NoError(t, err)
 	assert.Equal(t, expectedResponse, actualResponse)
 }
@@ -449,13 +461,14 @@ func TestUpdatePond_NotFound(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.PUT("/pond/:id", pondHandler.UpdatePond)
 
 	// Create a farm for testing
@@ -466,7 +479,7 @@ func TestUpdatePond_NotFound(t *testing.T) {
 
 	// Create a test request with a non-existing ID
 	requestBody := strings.NewReader(`{"name": "Updated Pond", "farm_id": 1}`)
-	request, _ := http.NewRequest("PUT", "/pond/1", requestBody)
+	request, _ := http.NewRequest("PUT", "/pond/42", requestBody)
 	request.Header.Set("User-Agent", "Test Agent")
 	request.Header.Set("Content-Type", "application/json")
 	responseRecorder := httptest.NewRecorder()
@@ -474,17 +487,18 @@ func TestUpdatePond_NotFound(t *testing.T) {
 	// Perform the request
 	router.ServeHTTP(responseRecorder, request)
 
-	// Check the response status code
-	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	// Check the response status code and location
+	assert.Equal(t, http.StatusCreated, responseRecorder.Code)
+	assert.Equal(t, "/pond/42", responseRecorder.Header().Get("Location"))
 
 	// Check the response body
 	expectedResponse := gin.H{
-		"code":    http.StatusOK,
+		"code":    http.StatusCreated,
 		"status":  "success",
 		"message": "Data Not Found. New pond created successfully",
-		"data": map[string]interface {}{
-			"id":   1,
-			"name": "Updated Pond",
+		"data": map[string]interface{}{
+			"id":      42,
+			"name":    "Updated Pond",
 			"farm_id": 1,
 		},
 	}
@@ -513,22 +527,80 @@ func TestUpdatePond_NotFound(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the pond was created in the repository
-	ponds, _ := pondRepo.Get()
+	ponds := listPonds(t, pondRepo)
 	assert.Len(t, ponds, 1)
+	assert.Equal(t, 42, ponds[0].ID)
 	assert.Equal(t, "Updated Pond", ponds[0].Name)
 }
 
+func TestUpdatePond_NotFoundStrict(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, true)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.PUT("/pond/:id", pondHandler.UpdatePond)
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{
+		ID:   1,
+		Name: "Farm 1",
+	})
+
+	// Create a test request with a non-existing ID
+	requestBody := strings.NewReader(`{"name": "Updated Pond", "farm_id": 1}`)
+	request, _ := http.NewRequest("PUT", "/pond/42", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusNotFound, responseRecorder.Code)
+
+	// Check the response body
+	expectedResponse := gin.H{
+		"code":    http.StatusNotFound,
+		"status":  "error",
+		"message": "Data Not Found",
+	}
+	actualResponse := gin.H{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	// Convert code to int if it exists
+	if codeFloat, ok := actualResponse["code"].(float64); ok {
+		code := int(codeFloat)
+		actualResponse["code"] = code
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResponse, actualResponse)
+
+	// Check that no pond was created in the repository
+	ponds := listPonds(t, pondRepo)
+	assert.Len(t, ponds, 0)
+}
+
 func TestDeletePond_NotFound(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.DELETE("/pond/:id", pondHandler.DeletePond)
 
 	// Create a farm for testing
@@ -539,8 +611,8 @@ func TestDeletePond_NotFound(t *testing.T) {
 
 	// Create a pond for testing
 	pondRepo.Create(&model.Pond{
-		ID:   1,
-		Name: "Pond 1",
+		ID:     1,
+		Name:   "Pond 1",
 		FarmID: 1,
 	})
 
@@ -574,7 +646,7 @@ func TestDeletePond_NotFound(t *testing.T) {
 	assert.Equal(t, expectedResponse, actualResponse)
 
 	// Check if the pond was not deleted from the repository
-	ponds, _ := pondRepo.Get()
+	ponds := listPonds(t, pondRepo)
 	assert.Len(t, ponds, 1)
 }
 
@@ -582,13 +654,14 @@ func TestCreatePond_InvalidPayload(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.POST("/pond", pondHandler.CreatePond)
 
 	// Create a test request with an invalid payload (missing name field)
@@ -627,13 +700,14 @@ func TestGetPondById_NotFound(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.GET("/pond/:id", pondHandler.GetPondById)
 
 	// Create a test request with a non-existing ID
@@ -670,13 +744,14 @@ func TestUpdatePond_InvalidPayload(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.PUT("/pond/:id", pondHandler.UpdatePond)
 
 	// Create a test request with an invalid payload (missing name field)
@@ -715,13 +790,14 @@ func TestDeletePond_InvalidParam(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.DELETE("/pond/:id", pondHandler.DeletePond)
 
 	// Create a test request with an invalid ID param
@@ -749,7 +825,7 @@ func TestDeletePond_InvalidParam(t *testing.T) {
 		code := int(codeFloat)
 		actualResponse["code"] = code
 	}
-	
+
 	assert.NoError(t, err)
 	assert.Equal(t, expectedResponse, actualResponse)
 }
@@ -758,13 +834,14 @@ func TestCreatePond_FarmDataNotFound(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.POST("/pond", pondHandler.CreatePond)
 
 	// Create a test request with a non-existing Farm ID
@@ -803,13 +880,14 @@ func TestUpdatePond_FarmDataNotFound(t *testing.T) {
 	// Create mock repositories
 	farmRepo := repository.NewMockFarmRepository()
 	pondRepo := repository.NewMockPondRepository()
-	logRepo := repository.NewMockLogRepository()
+	auditRepo := repository.NewMockAuditRepository()
 
 	// Create handler with mock repositories
-	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, logRepo)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
 
 	// Create a Gin router and set up the handler route
 	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
 	router.PUT("/pond/:id", pondHandler.UpdatePond)
 
 	// Create a test request with a non-existing Farm ID
@@ -842,4 +920,664 @@ func TestUpdatePond_FarmDataNotFound(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedResponse, actualResponse)
-}
\ No newline at end of file
+}
+func TestGetPond_FilterFarmId(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/pond", pondHandler.GetPond)
+
+	// Create ponds for testing
+	pondRepo.Create(&model.Pond{Name: "Pond B", FarmID: 1})
+	pondRepo.Create(&model.Pond{Name: "Pond A", FarmID: 1})
+	pondRepo.Create(&model.Pond{Name: "Pond C", FarmID: 2})
+
+	// Create a test request
+	request, _ := http.NewRequest("GET", "/pond?farm_id=1&sort=name", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Data       []model.Pond     `json:"data"`
+		Pagination model.Pagination `json:"pagination"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Pond{{ID: 2, Name: "Pond A", FarmID: 1}, {ID: 1, Name: "Pond B", FarmID: 1}}, actualResponse.Data)
+	assert.Equal(t, model.Pagination{Page: 1, PageSize: 20, Total: 2}, actualResponse.Pagination)
+}
+
+func TestCreateFarmPond(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm/:id/ponds", pondHandler.CreateFarmPond)
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{
+		ID:   1,
+		Name: "Farm 1",
+	})
+
+	// Create a test request
+	requestBody := strings.NewReader(`{"name": "Pond 1"}`)
+	request, _ := http.NewRequest("POST", "/farm/1/ponds", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Message string     `json:"message"`
+		Data    model.Pond `json:"data"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "New pond created successfully", actualResponse.Message)
+	assert.Equal(t, model.Pond{ID: 1, Name: "Pond 1", FarmID: 1}, actualResponse.Data)
+
+	// Check if the pond was created in the repository
+	ponds := listPonds(t, pondRepo)
+	assert.Len(t, ponds, 1)
+	assert.Equal(t, 1, ponds[0].FarmID)
+}
+
+func TestCreatePond_IgnoresDeletedAt(t *testing.T) {
+	// Create mock repositories
+	pondRepo := repository.NewMockPondRepository()
+	farmRepo := repository.NewMockFarmRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/pond", pondHandler.CreatePond)
+	router.POST("/farm/:id/ponds", pondHandler.CreateFarmPond)
+	router.PUT("/pond/:id", pondHandler.UpdatePond)
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+
+	testCases := []struct {
+		method       string
+		path         string
+		name         string
+		id           int
+		expectedCode int
+	}{
+		{"POST", "/pond", "Pond 1", 1, http.StatusOK},
+		{"POST", "/farm/1/ponds", "Pond 2", 2, http.StatusOK},
+		{"PUT", "/pond/3", "Pond 3", 3, http.StatusCreated},
+		{"PUT", "/pond/1", "Pond 4", 1, http.StatusOK},
+	}
+	for _, testCase := range testCases {
+		// Perform a request carrying a deleted_at
+		requestBody := strings.NewReader(`{"name": "` + testCase.name + `", "farm_id": 1, "deleted_at": "2020-01-01T00:00:00Z"}`)
+		request, _ := http.NewRequest(testCase.method, testCase.path, requestBody)
+		request.Header.Set("Content-Type", "application/json")
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the pond is stored and returned without it
+		assert.Equal(t, testCase.expectedCode, responseRecorder.Code, testCase.method+" "+testCase.path)
+		assert.NotContains(t, responseRecorder.Body.String(), "2020-01-01", testCase.method+" "+testCase.path)
+		pond, err := pondRepo.GetById(testCase.id)
+		if assert.NoError(t, err, testCase.method+" "+testCase.path) {
+			assert.Nil(t, pond.DeletedAt, testCase.method+" "+testCase.path)
+		}
+	}
+}
+
+func TestCreateFarmPond_FarmDataNotFound(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/farm/:id/ponds", pondHandler.CreateFarmPond)
+
+	// Create a test request with a non-existing farm
+	requestBody := strings.NewReader(`{"name": "Pond 1"}`)
+	request, _ := http.NewRequest("POST", "/farm/1/ponds", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusNotFound, responseRecorder.Code)
+
+	// Check the response body
+	expectedResponse := gin.H{
+		"code":    float64(http.StatusNotFound),
+		"status":  "error",
+		"message": "Farm Data Not Found",
+	}
+	actualResponse := gin.H{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResponse, actualResponse)
+}
+
+func TestGetFarmPond(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm/:id/ponds", pondHandler.GetFarmPond)
+
+	// Create farms and ponds for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farmRepo.Create(&model.Farm{Name: "Farm 2"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+	pondRepo.Create(&model.Pond{Name: "Pond 2", FarmID: 2})
+
+	// Create a test request, the farm_id query is overridden by the path
+	request, _ := http.NewRequest("GET", "/farm/2/ponds?farm_id=1", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Data []model.Pond `json:"data"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Pond{{ID: 2, Name: "Pond 2", FarmID: 2}}, actualResponse.Data)
+}
+
+func TestGetFarmPond_FarmDataNotFound(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/farm/:id/ponds", pondHandler.GetFarmPond)
+
+	// Create a test request with a non-existing farm
+	request, _ := http.NewRequest("GET", "/farm/1/ponds", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusNotFound, responseRecorder.Code)
+}
+
+func TestRestorePond_IfMatch(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/pond/:id/restore", pondHandler.RestorePond)
+
+	// Create a deleted pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+	pond, _ := pondRepo.GetById(1)
+	pondRepo.Delete(pond)
+
+	// Check a stale ETag is refused and the current one restores the pond
+	for _, test := range []struct {
+		tag  string
+		code int
+	}{
+		{`"1"`, http.StatusPreconditionFailed},
+		{`"2"`, http.StatusOK},
+	} {
+		request, _ := http.NewRequest("POST", "/pond/1/restore", nil)
+		request.Header.Set("User-Agent", "Test Agent")
+		request.Header.Set("If-Match", test.tag)
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, test.code, responseRecorder.Code, test.tag)
+	}
+	restored, err := pondRepo.GetById(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, restored.Version)
+}
+
+func TestRestorePond_FarmDataNotFound(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/pond/:id/restore", pondHandler.RestorePond)
+
+	// Create a deleted farm and pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+	pond, _ := pondRepo.GetById(1)
+	pondRepo.Delete(pond)
+	farm, _ := farmRepo.GetById(1)
+	farmRepo.Delete(farm)
+
+	// Create a test request
+	request, _ := http.NewRequest("POST", "/pond/1/restore", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusNotFound, responseRecorder.Code)
+
+	// Check the response body
+	expectedResponse := gin.H{
+		"code":    float64(http.StatusNotFound),
+		"status":  "error",
+		"message": "Farm Data Not Found",
+	}
+	actualResponse := gin.H{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResponse, actualResponse)
+
+	// Check if the pond is still deleted
+	ponds := listPonds(t, pondRepo)
+	assert.Len(t, ponds, 0)
+}
+
+func TestGetPondHistory_NotFound(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/pond/:id/history", pondHandler.GetPondHistory)
+
+	// Create a test request with a pond without history
+	request, _ := http.NewRequest("GET", "/pond/1/history", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusNotFound, responseRecorder.Code)
+}
+
+func TestGetPondHistory_Empty(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/pond/:id/history", pondHandler.GetPondHistory)
+
+	// Create a pond stored before audits were recorded
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	// Create a test request
+	request, _ := http.NewRequest("GET", "/pond/1/history", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	var actualResponse struct {
+		Data []model.Audit `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse))
+	assert.NotNil(t, actualResponse.Data)
+	assert.Empty(t, actualResponse.Data)
+}
+
+func TestPatchPond(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.PATCH("/pond/:id", pondHandler.PatchPond)
+
+	// Create farms and a pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	farmRepo.Create(&model.Farm{Name: "Farm 2"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	// Create a test request changing only the farm
+	requestBody := strings.NewReader(`{"farm_id": 2}`)
+	request, _ := http.NewRequest("PATCH", "/pond/1", requestBody)
+	request.Header.Set("User-Agent", "Test Agent")
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Message string     `json:"message"`
+		Data    model.Pond `json:"data"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Pond updated successfully", actualResponse.Message)
+	assert.Equal(t, model.Pond{ID: 1, Name: "Pond 1", FarmID: 2}, actualResponse.Data)
+
+	// Check if the pond was updated in the repository
+	pond, _ := pondRepo.GetById(1)
+	assert.Equal(t, "Pond 1", pond.Name)
+	assert.Equal(t, 2, pond.FarmID)
+}
+
+func TestPatchPond_InvalidPayload(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.PATCH("/pond/:id", pondHandler.PatchPond)
+
+	// Create a farm and pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	for _, body := range []string{`{"name": null}`, `{"farm_id": "two"}`, `not json`} {
+		// Create a test request with an invalid patch
+		request, _ := http.NewRequest("PATCH", "/pond/1", strings.NewReader(body))
+		request.Header.Set("User-Agent", "Test Agent")
+		request.Header.Set("Content-Type", "application/merge-patch+json")
+		responseRecorder := httptest.NewRecorder()
+
+		// Perform the request
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the response status code
+		assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	}
+}
+
+func TestGetPondById_ETag(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/pond/:id", pondHandler.GetPondById)
+
+	// Create a pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	// Check the ETag is returned
+	request, _ := http.NewRequest("GET", "/pond/1", nil)
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, `"1"`, responseRecorder.Header().Get("ETag"))
+
+	// Check a matching If-None-Match is not modified
+	request, _ = http.NewRequest("GET", "/pond/1", nil)
+	request.Header.Set("If-None-Match", `"1"`)
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusNotModified, responseRecorder.Code)
+	assert.Empty(t, responseRecorder.Body.String())
+}
+
+func TestPatchPond_IfMatch(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.PUT("/pond/:id", pondHandler.UpdatePond)
+	router.PATCH("/pond/:id", pondHandler.PatchPond)
+	router.DELETE("/pond/:id", pondHandler.DeletePond)
+
+	// Create a pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	// Check the current version is patched
+	request, _ := http.NewRequest("PATCH", "/pond/1", strings.NewReader(`{"name": "Pond 2"}`))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	request.Header.Set("If-Match", `"1"`)
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Equal(t, `"2"`, responseRecorder.Header().Get("ETag"))
+
+	// Check a stale version is refused
+	for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+		request, _ = http.NewRequest(method, "/pond/1", strings.NewReader(`{"name": "Pond 3", "farm_id": 1}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("If-Match", `"1"`)
+		responseRecorder = httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+		assert.Equal(t, http.StatusPreconditionFailed, responseRecorder.Code)
+	}
+
+	ponds := listPonds(t, pondRepo)
+	assert.Equal(t, []model.Pond{{ID: 1, Name: "Pond 2", FarmID: 1, Version: 2, OrganizationID: model.DefaultOrganizationID}}, ponds)
+}
+
+func TestBulkPond(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/pond/bulk", pondHandler.BulkPond)
+	router.GET("/pond/:id", pondHandler.GetPondById)
+
+	// Create a farm and pond for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+	pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1})
+
+	// Create a test request
+	requestBody := strings.NewReader(`[
+		{"operation": "create", "name": "Pond 2", "farm_id": 1},
+		{"operation": "create", "name": "Pond 3", "farm_id": 1},
+		{"operation": "update", "id": 1, "name": "Pond 4", "farm_id": 1}
+	]`)
+	request, _ := http.NewRequest("POST", "/pond/bulk", requestBody)
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the ponds in the repository
+	ponds, _ := pondRepo.GetByFarmId(1)
+	expectedPonds := []model.Pond{
+		{ID: 1, Name: "Pond 4", FarmID: 1, Version: 2, OrganizationID: model.DefaultOrganizationID},
+		{ID: 2, Name: "Pond 2", FarmID: 1, Version: 1, OrganizationID: model.DefaultOrganizationID},
+		{ID: 3, Name: "Pond 3", FarmID: 1, Version: 1, OrganizationID: model.DefaultOrganizationID},
+	}
+	assert.Equal(t, expectedPonds, ponds)
+}
+
+func TestBulkPond_BestEffort(t *testing.T) {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	auditRepo := repository.NewMockAuditRepository()
+
+	// Create handler with mock repositories
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.POST("/pond/bulk", pondHandler.BulkPond)
+
+	// Create a farm for testing
+	farmRepo.Create(&model.Farm{Name: "Farm 1"})
+
+	requestBody := `[
+		{"operation": "create", "name": "Pond 1", "farm_id": 1},
+		{"operation": "create", "name": "Pond 2", "farm_id": 2},
+		{"operation": "delete", "id": 5}
+	]`
+	expectedResults := []model.BulkResult{
+		{Index: 0, Operation: model.BulkCreate, Status: model.BulkSuccess, Code: http.StatusOK},
+		{Index: 1, Operation: model.BulkCreate, Status: model.BulkError, Code: http.StatusNotFound, Message: "Farm Data Not Found"},
+		{Index: 2, Operation: model.BulkDelete, Status: model.BulkError, Code: http.StatusNotFound, Message: "Data Not Found"},
+	}
+
+	// Check the all-or-nothing request applies nothing
+	request, _ := http.NewRequest("POST", "/pond/bulk", strings.NewReader(requestBody))
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder := httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+	ponds := listPonds(t, pondRepo)
+	assert.Len(t, ponds, 0)
+
+	// Check the best-effort request applies the valid pond
+	request, _ = http.NewRequest("POST", "/pond/bulk?atomic=false", strings.NewReader(requestBody))
+	request.Header.Set("Content-Type", "application/json")
+	responseRecorder = httptest.NewRecorder()
+	router.ServeHTTP(responseRecorder, request)
+	assert.Equal(t, http.StatusMultiStatus, responseRecorder.Code)
+
+	var response struct {
+		Data []model.BulkResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+	for i := range response.Data {
+		response.Data[i].Data = nil
+	}
+	assert.Equal(t, expectedResults, response.Data)
+
+	ponds = listPonds(t, pondRepo)
+	assert.Equal(t, []model.Pond{{ID: 1, Name: "Pond 1", FarmID: 1, Version: 1, OrganizationID: model.DefaultOrganizationID}}, ponds)
+}
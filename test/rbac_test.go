@@ -0,0 +1,261 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withRole stands in for RequireScope, giving every request role in the
+// default organization as the API key Test with id 1
+func withRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(handler.ContextSubject, "Test")
+		c.Set(handler.ContextMember, model.ApiKeyMember(1))
+		c.Set(handler.ContextRole, role)
+		c.Set(handler.ContextOrganization, model.DefaultOrganizationID)
+		c.Next()
+	}
+}
+
+// newPolicyRouter routes every farm, pond, membership, statistics, log export
+// and import endpoint for requests with role, over a farm with a pond, a farm without
+// ponds, and a deleted farm and pond, all of which Test is a member of
+func newPolicyRouter(t *testing.T, role string) *gin.Engine {
+	// Create mock repositories
+	farmRepo := repository.NewMockFarmRepository()
+	pondRepo := repository.NewMockPondRepository()
+	farmMemberRepo := repository.NewMockFarmMemberRepository()
+	farmRepo.LinkPondRepository(pondRepo)
+	farmRepo.LinkFarmMemberRepository(farmMemberRepo)
+	auditRepo := repository.NewMockAuditRepository()
+	logRepo := repository.NewMockLogRepository()
+
+	// Create farms, ponds and audits for testing
+	deletedFarm := &model.Farm{Name: "Farm 3"}
+	deletedPond := &model.Pond{Name: "Pond 2", FarmID: 1}
+	require.NoError(t, farmRepo.Create(&model.Farm{Name: "Farm 1"}))
+	require.NoError(t, farmRepo.Create(&model.Farm{Name: "Farm 2"}))
+	require.NoError(t, farmRepo.Create(deletedFarm))
+	require.NoError(t, farmRepo.Delete(deletedFarm))
+	require.NoError(t, pondRepo.Create(&model.Pond{Name: "Pond 1", FarmID: 1}))
+	require.NoError(t, pondRepo.Create(deletedPond))
+	require.NoError(t, pondRepo.Delete(deletedPond))
+	for _, farmId := range []int{1, 2, 3} {
+		require.NoError(t, farmMemberRepo.Grant(&model.FarmMember{FarmID: farmId, Subject: model.ApiKeyMember(1), CreatedAt: time.Now().UTC()}))
+	}
+	for _, entity := range []string{model.AuditEntityFarm, model.AuditEntityPond} {
+		require.NoError(t, auditRepo.Create(&model.Audit{CreatedAt: time.Now().UTC(), Entity: entity, EntityID: 1, Operation: model.AuditCreate, Actor: "Test"}))
+	}
+
+	// Create handlers with mock repositories
+	farmHandler := handler.NewFarmHandler(farmRepo, pondRepo, auditRepo, false)
+	pondHandler := handler.NewPondHandler(pondRepo, farmRepo, auditRepo, false)
+	statisticsHandler := handler.NewStatisticsHandler(logRepo)
+	logHandler := handler.NewLogHandler(logRepo)
+	importHandler := handler.NewImportHandler(farmRepo, pondRepo)
+	membershipHandler := handler.NewMembershipHandler(farmRepo, farmMemberRepo)
+
+	// Create a Gin router and set up the handler routes
+	router := gin.Default()
+	router.Use(withRole(role))
+	router.POST("/farm/", farmHandler.CreateFarm)
+	router.POST("/farm/bulk", farmHandler.BulkFarm)
+	router.GET("/farm/", farmHandler.GetFarm)
+	router.GET("/farm/export", farmHandler.ExportFarm)
+	router.GET("/farm/:id", farmHandler.GetFarmById)
+	router.PUT("/farm/:id", farmHandler.UpdateFarm)
+	router.PATCH("/farm/:id", farmHandler.PatchFarm)
+	router.DELETE("/farm/:id", farmHandler.DeleteFarm)
+	router.POST("/farm/:id/restore", farmHandler.RestoreFarm)
+	router.GET("/farm/:id/history", farmHandler.GetFarmHistory)
+	router.GET("/farm/:id/ponds", pondHandler.GetFarmPond)
+	router.POST("/farm/:id/ponds", pondHandler.CreateFarmPond)
+	router.POST("/pond/", pondHandler.CreatePond)
+	router.POST("/pond/bulk", pondHandler.BulkPond)
+	router.GET("/pond/", pondHandler.GetPond)
+	router.GET("/pond/export", pondHandler.ExportPond)
+	router.GET("/pond/:id", pondHandler.GetPondById)
+	router.PUT("/pond/:id", pondHandler.UpdatePond)
+	router.PATCH("/pond/:id", pondHandler.PatchPond)
+	router.DELETE("/pond/:id", pondHandler.DeletePond)
+	router.POST("/pond/:id/restore", pondHandler.RestorePond)
+	router.GET("/pond/:id/history", pondHandler.GetPondHistory)
+	router.GET("/farm/:id/members", membershipHandler.GetFarmMember)
+	router.POST("/farm/:id/members", membershipHandler.CreateFarmMember)
+	router.DELETE("/farm/:id/members/:subject", membershipHandler.DeleteFarmMember)
+	router.GET("/statistics", statisticsHandler.GetStatistics)
+	router.GET("/log/export", logHandler.ExportLog)
+	router.POST("/import", importHandler.ImportFarmPond)
+	return router
+}
+
+func TestRolePolicy(t *testing.T) {
+	everyone := []string{model.RoleViewer, model.RoleOperator, model.RoleAdmin}
+	writers := []string{model.RoleOperator, model.RoleAdmin}
+	admins := []string{model.RoleAdmin}
+
+	testCases := []struct {
+		method       string
+		path         string
+		body         string
+		allowed      []string
+		expectedCode int
+	}{
+		{"POST", "/farm/", `{"name": "Farm 4"}`, writers, http.StatusOK},
+		{"POST", "/farm/bulk", `[{"operation": "create", "name": "Farm 4"}, {"operation": "update", "id": 1, "name": "Farm 5"}]`, writers, http.StatusOK},
+		{"POST", "/farm/bulk", `[{"operation": "create", "name": "Farm 4"}, {"operation": "delete", "id": 2}]`, admins, http.StatusOK},
+		{"GET", "/farm/", "", everyone, http.StatusOK},
+		{"GET", "/farm/export", "", everyone, http.StatusOK},
+		{"GET", "/farm/1", "", everyone, http.StatusOK},
+		{"PUT", "/farm/1", `{"name": "Farm 4"}`, writers, http.StatusOK},
+		{"PUT", "/farm/9", `{"name": "Farm 9"}`, writers, http.StatusCreated},
+		{"PATCH", "/farm/1", `{"name": "Farm 4"}`, writers, http.StatusOK},
+		{"DELETE", "/farm/2", "", admins, http.StatusOK},
+		{"POST", "/farm/3/restore", "", admins, http.StatusOK},
+		{"GET", "/farm/1/history", "", everyone, http.StatusOK},
+		{"GET", "/farm/1/ponds", "", everyone, http.StatusOK},
+		{"POST", "/farm/1/ponds", `{"name": "Pond 4"}`, writers, http.StatusOK},
+		{"POST", "/pond/", `{"name": "Pond 4", "farm_id": 1}`, writers, http.StatusOK},
+		{"POST", "/pond/bulk", `[{"operation": "create", "name": "Pond 4", "farm_id": 1}, {"operation": "update", "id": 1, "name": "Pond 5", "farm_id": 2}]`, writers, http.StatusOK},
+		{"POST", "/pond/bulk", `[{"operation": "delete", "id": 1}]`, admins, http.StatusOK},
+		{"GET", "/pond/", "", everyone, http.StatusOK},
+		{"GET", "/pond/export", "", everyone, http.StatusOK},
+		{"GET", "/pond/1", "", everyone, http.StatusOK},
+		{"PUT", "/pond/1", `{"name": "Pond 4", "farm_id": 2}`, writers, http.StatusOK},
+		{"PUT", "/pond/9", `{"name": "Pond 9", "farm_id": 1}`, writers, http.StatusCreated},
+		{"PATCH", "/pond/1", `{"name": "Pond 4"}`, writers, http.StatusOK},
+		{"DELETE", "/pond/1", "", admins, http.StatusOK},
+		{"POST", "/pond/2/restore", "", admins, http.StatusOK},
+		{"GET", "/pond/1/history", "", everyone, http.StatusOK},
+		{"GET", "/farm/1/members", "", admins, http.StatusOK},
+		{"POST", "/farm/1/members", `{"subject": "apikey:2"}`, admins, http.StatusOK},
+		{"DELETE", "/farm/1/members/apikey:1", "", admins, http.StatusOK},
+		{"GET", "/statistics", "", everyone, http.StatusOK},
+		{"GET", "/log/export", "", admins, http.StatusOK},
+		{"POST", "/import", "Farm,Pond\nFarm 4,Pond 4\n", writers, http.StatusOK},
+	}
+	for _, role := range []string{model.RoleViewer, model.RoleOperator, model.RoleAdmin, "owner", ""} {
+		for _, testCase := range testCases {
+			name := fmt.Sprintf("%q %s %s %s", role, testCase.method, testCase.path, testCase.body)
+			router := newPolicyRouter(t, role)
+
+			// Perform the request
+			var request *http.Request
+			if testCase.path == "/import" {
+				request = newImportRequest(t, testCase.path, "inventory.csv", []byte(testCase.body))
+			} else {
+				request, _ = http.NewRequest(testCase.method, testCase.path, strings.NewReader(testCase.body))
+				request.Header.Set("Content-Type", "application/json")
+				if testCase.method == "PATCH" {
+					request.Header.Set("Content-Type", "application/merge-patch+json")
+				}
+			}
+			responseRecorder := httptest.NewRecorder()
+			router.ServeHTTP(responseRecorder, request)
+
+			// Check the response
+			allowed := false
+			for _, allowedRole := range testCase.allowed {
+				allowed = allowed || allowedRole == role
+			}
+			if allowed {
+				assert.Equal(t, testCase.expectedCode, responseRecorder.Code, name)
+			} else {
+				assert.Equal(t, http.StatusForbidden, responseRecorder.Code, name)
+				assert.Contains(t, strings.ToLower(responseRecorder.Body.String()), "role", name)
+			}
+		}
+	}
+}
+
+func TestRolePolicy_UnknownBulkOperation(t *testing.T) {
+	for _, role := range []string{model.RoleViewer, model.RoleAdmin, ""} {
+		for _, path := range []string{"/farm/bulk", "/pond/bulk"} {
+			router := newPolicyRouter(t, role)
+
+			// Perform the request
+			body := `[{"operation": "create", "name": "Item 4", "farm_id": 1}, {"operation": "upsert", "name": "Item 5", "farm_id": 1}]`
+			request, _ := http.NewRequest("POST", path+"?atomic=false", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			responseRecorder := httptest.NewRecorder()
+			router.ServeHTTP(responseRecorder, request)
+
+			// Check the batch is rejected whatever the role, as an unknown
+			// operation cannot be authorized
+			name := fmt.Sprintf("%q %s", role, path)
+			assert.Equal(t, http.StatusBadRequest, responseRecorder.Code, name)
+			var response struct {
+				Data []model.BulkResult `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response), name)
+			assert.Equal(t, []model.BulkResult{
+				{Index: 0, Operation: model.BulkCreate, Status: model.BulkSkipped},
+				{Index: 1, Operation: "upsert", Status: model.BulkError, Code: http.StatusBadRequest, Message: "Invalid request payload"},
+			}, response.Data, name)
+		}
+	}
+}
+
+func TestRolePolicy_Credentials(t *testing.T) {
+	verifier, _ := newJwtVerifier(t)
+	router, apiKeyRepo, _, _ := newAuthRouter(verifier)
+
+	// Create keys and tokens for testing
+	operator := createApiKey(t, apiKeyRepo, "Operator", "farm:write", model.RoleOperator)
+	viewer := createApiKey(t, apiKeyRepo, "Viewer", "farm:write", model.RoleViewer)
+	token := func(role interface{}) string {
+		claims := jwt.MapClaims{"iss": testIssuerSecret, "sub": "user-1", "aud": "delos", "exp": time.Now().Add(time.Hour).Unix(), "scope": "farm:write", "org": "default"}
+		if role != nil {
+			claims["role"] = role
+		}
+		return "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims)
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		key           string
+		authorization string
+		expectedCode  int
+		expectedBody  string
+	}{
+		{"Operator key creates", "POST", operator, "", http.StatusOK, ""},
+		{"Viewer key reads", "GET", viewer, "", http.StatusOK, ""},
+		{"Viewer key creates", "POST", viewer, "", http.StatusForbidden, "Role viewer is not allowed to create farm"},
+		{"Operator token creates", "POST", "", token(model.RoleOperator), http.StatusOK, ""},
+		{"Viewer token creates", "POST", "", token(model.RoleViewer), http.StatusForbidden, "Role viewer is not allowed to create farm"},
+		{"Unknown role token reads", "GET", "", token("owner"), http.StatusForbidden, "Role owner is not allowed to read farm"},
+		{"Malformed role token reads", "GET", "", token([]string{model.RoleAdmin}), http.StatusForbidden, "Missing role"},
+		{"Token without role reads", "GET", "", token(nil), http.StatusForbidden, "Missing role"},
+	}
+	for i, testCase := range testCases {
+		// Perform the request
+		request, _ := http.NewRequest(testCase.method, "/farm/", strings.NewReader(fmt.Sprintf(`{"name": "Farm %d"}`, i+1)))
+		request.Header.Set("Content-Type", "application/json")
+		if testCase.key != "" {
+			request.Header.Set(handler.ApiKeyHeader, testCase.key)
+		}
+		if testCase.authorization != "" {
+			request.Header.Set("Authorization", testCase.authorization)
+		}
+		responseRecorder := httptest.NewRecorder()
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the response
+		assert.Equal(t, testCase.expectedCode, responseRecorder.Code, testCase.name)
+		assert.Contains(t, responseRecorder.Body.String(), testCase.expectedBody, testCase.name)
+	}
+}
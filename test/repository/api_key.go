@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
+)
+
+// MockApiKeyRepository is a mock implementation of the ApiKeyRepository
+// interface
+type MockApiKeyRepository struct {
+	apiKeys []*model.ApiKey
+}
+
+func NewMockApiKeyRepository() *MockApiKeyRepository {
+	return &MockApiKeyRepository{
+		apiKeys: make([]*model.ApiKey, 0),
+	}
+}
+
+func (m *MockApiKeyRepository) Create(apiKey *model.ApiKey) error {
+	for _, existingApiKey := range m.apiKeys {
+		if existingApiKey.KeyHash == apiKey.KeyHash {
+			return errDuplicateHash
+		}
+	}
+	apiKey.ID = len(m.apiKeys) + 1
+	copied := *apiKey
+	m.apiKeys = append(m.apiKeys, &copied)
+	return nil
+}
+
+func (m *MockApiKeyRepository) List() ([]model.ApiKey, error) {
+	apiKeys := make([]model.ApiKey, 0, len(m.apiKeys))
+	for _, apiKey := range m.apiKeys {
+		apiKeys = append(apiKeys, *apiKey)
+	}
+	return apiKeys, nil
+}
+
+func (m *MockApiKeyRepository) GetActiveByHash(keyHash string) (*model.ApiKey, error) {
+	for _, apiKey := range m.apiKeys {
+		if apiKey.KeyHash == keyHash && apiKey.RevokedAt == nil {
+			copied := *apiKey
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockApiKeyRepository) Revoke(id int) error {
+	for _, apiKey := range m.apiKeys {
+		if apiKey.ID == id && apiKey.RevokedAt == nil {
+			revokedAt := time.Now().UTC()
+			apiKey.RevokedAt = &revokedAt
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
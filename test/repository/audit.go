@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+)
+
+// MockAuditRepository is a mock implementation of the AuditRepository interface
+type MockAuditRepository struct {
+	audits []*model.Audit
+	err    error
+}
+
+func NewMockAuditRepository() *MockAuditRepository {
+	return &MockAuditRepository{
+		audits: make([]*model.Audit, 0),
+	}
+}
+
+// Fail makes every following Create return err, as a database refusing the
+// audit would
+func (m *MockAuditRepository) Fail(err error) {
+	m.err = err
+}
+
+func (m *MockAuditRepository) Create(audit *model.Audit) error {
+	if m.err != nil {
+		return m.err
+	}
+	audit.ID = len(m.audits) + 1
+	m.audits = append(m.audits, audit)
+	return nil
+}
+
+func (m *MockAuditRepository) GetByEntity(entity string, entityId int) ([]model.Audit, error) {
+	audits := make([]model.Audit, 0)
+	for _, audit := range m.audits {
+		if audit.Entity == entity && audit.EntityID == entityId {
+			audits = append(audits, *audit)
+		}
+	}
+	return audits, nil
+}
+
+// record stores the audit of a mutation like the repositories, before being
+// nil for creations
+func (m *MockAuditRepository) record(actor string, entity string, entityId int, operation string, before interface{}, after interface{}) error {
+	audit := model.Audit{
+		CreatedAt: time.Now().UTC(),
+		Entity:    entity,
+		EntityID:  entityId,
+		Operation: operation,
+		Actor:     actor,
+	}
+	if before != nil {
+		audit.Before, _ = json.Marshal(before)
+	}
+	audit.After, _ = json.Marshal(after)
+	return m.Create(&audit)
+}
+
+// restore drops the audits stored since the repository held count of them, to
+// roll back a failed transaction
+func (m *MockAuditRepository) restore(count int) {
+	m.audits = m.audits[:count]
+}
@@ -1,65 +1,459 @@
 package repository
 
 import (
-	"errors"
-	
+	"sort"
+	"time"
+
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	gormRepository "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"gorm.io/gorm"
 )
 
-// MockFarmRepository is a mock implementation of the FarmRepository interface
+// MockFarmRepository is a mock implementation of the FarmRepository interface.
+// It stores and returns copies, and enforces the same constraints as the
+// database, see the contract tests in test/repository_contract_test.go. It
+// starts in the default organization, and InOrganization and ForMember return
+// views of the same farms scoped to another organization or to a member.
+// AuditedBy stores audits in the linked MockAuditRepository.
 type MockFarmRepository struct {
-	farms      map[int]*model.Farm
+	farms          map[int]*model.Farm
+	pondRepo       *MockPondRepository
+	memberRepo     *MockFarmMemberRepository
+	auditRepo      *MockAuditRepository
+	organizationId int
+	memberOnly     bool
+	subject        string
+	actor          string
 }
 
 func NewMockFarmRepository() *MockFarmRepository {
 	return &MockFarmRepository{
-		farms:      make(map[int]*model.Farm),
+		farms:          make(map[int]*model.Farm),
+		organizationId: model.DefaultOrganizationID,
+	}
+}
+
+func (m *MockFarmRepository) InOrganization(organizationId int) gormRepository.FarmRepository {
+	return m.inOrganization(organizationId)
+}
+
+func (m *MockFarmRepository) inOrganization(organizationId int) *MockFarmRepository {
+	return &MockFarmRepository{
+		farms:          m.farms,
+		pondRepo:       m.pondRepo,
+		memberRepo:     m.memberRepo,
+		auditRepo:      m.auditRepo,
+		organizationId: organizationId,
+	}
+}
+
+func (m *MockFarmRepository) ForMember(subject string) gormRepository.FarmRepository {
+	scoped := m.inOrganization(m.organizationId)
+	scoped.memberOnly = true
+	scoped.subject = subject
+	return scoped
+}
+
+func (m *MockFarmRepository) AuditedBy(actor string) gormRepository.FarmRepository {
+	audited := *m
+	audited.actor = actor
+	return &audited
+}
+
+// ponds returns the linked pond repository with the same scope
+func (m *MockFarmRepository) ponds() *MockPondRepository {
+	scoped := m.pondRepo.inOrganization(m.organizationId)
+	scoped.memberOnly = m.memberOnly
+	scoped.subject = m.subject
+	scoped.actor = m.actor
+	return scoped
+}
+
+// audit stores the audit of a mutation turning before into after, when
+// audited. Mutations call it before storing after, so a failing audit leaves
+// the farms untouched like the rolled back transaction of the database.
+func (m *MockFarmRepository) audit(operation string, before *model.Farm, after model.Farm) error {
+	if m.actor == "" || m.auditRepo == nil {
+		return nil
+	}
+	if before == nil {
+		return m.auditRepo.record(m.actor, model.AuditEntityFarm, after.ID, operation, nil, after)
+	}
+	return m.auditRepo.record(m.actor, model.AuditEntityFarm, after.ID, operation, *before, after)
+}
+
+// reaches reports whether the farm with id is within reach of the member, if
+// any
+func (m *MockFarmRepository) reaches(id int) bool {
+	return !m.memberOnly || (m.memberRepo != nil && m.memberRepo.isMember(id, m.subject))
+}
+
+// get returns the stored farm with id if it belongs to the organization and is
+// within reach, deleted or not
+func (m *MockFarmRepository) get(id int) (*model.Farm, bool) {
+	farm, ok := m.farms[id]
+	if !ok || farm.OrganizationID != m.organizationId || !m.reaches(id) {
+		return nil, false
+	}
+	return farm, true
+}
+
+// LinkPondRepository lets DeleteWithPonds remove ponds from pondRepo, and
+// pondRepo check and export the farms of its ponds
+func (m *MockFarmRepository) LinkPondRepository(pondRepo *MockPondRepository) {
+	m.pondRepo = pondRepo
+	pondRepo.farmRepo = m
+}
+
+// LinkAuditRepository lets AuditedBy store audits in auditRepo
+func (m *MockFarmRepository) LinkAuditRepository(auditRepo *MockAuditRepository) {
+	m.auditRepo = auditRepo
+}
+
+// LinkFarmMemberRepository lets ForMember reach the farms granted in
+// memberRepo, and memberRepo check the farms it grants
+func (m *MockFarmRepository) LinkFarmMemberRepository(memberRepo *MockFarmMemberRepository) {
+	m.memberRepo = memberRepo
+	memberRepo.farmRepo = m
+}
+
+// nameTaken reports whether a farm of the organization other than id has
+// name, deleted or not
+func (m *MockFarmRepository) nameTaken(name string, id int) bool {
+	for _, farm := range m.farms {
+		if farm.Name == name && farm.ID != id && farm.OrganizationID == m.organizationId {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot copies the farms, to roll back a failed transaction
+func (m *MockFarmRepository) snapshot() map[int]*model.Farm {
+	snapshot := make(map[int]*model.Farm, len(m.farms))
+	for id, farm := range m.farms {
+		copied := *farm
+		snapshot[id] = &copied
+	}
+	return snapshot
+}
+
+// restore puts back the farms of a snapshot in place, as scoped views share
+// the map
+func (m *MockFarmRepository) restore(snapshot map[int]*model.Farm) {
+	for id := range m.farms {
+		delete(m.farms, id)
+	}
+	for id, farm := range snapshot {
+		m.farms[id] = farm
 	}
 }
 
 func (m *MockFarmRepository) Create(farm *model.Farm) error {
-	farm.ID = len(m.farms) + 1
-	m.farms[farm.ID] = farm
+	operation := model.AuditCreate
+	if farm.ID != 0 {
+		operation = model.AuditUpsert
+	}
+	if existing, ok := m.farms[farm.ID]; ok {
+		if existing.OrganizationID != m.organizationId || !m.reaches(existing.ID) {
+			return gormRepository.ErrIdTaken
+		}
+		return errDuplicateId
+	}
+	if m.nameTaken(farm.Name, 0) {
+		return errDuplicateName
+	}
+
+	// Keep a preset id, as PUT creates the farm at the id in the path, and
+	// never reuse the id of a deleted farm, ids being shared by organizations
+	if farm.ID == 0 {
+		for id := range m.farms {
+			if id > farm.ID {
+				farm.ID = id
+			}
+		}
+		farm.ID++
+	}
+	if farm.Version == 0 {
+		farm.Version = 1
+	}
+	farm.OrganizationID = m.organizationId
+	if err := m.audit(operation, nil, *farm); err != nil {
+		return err
+	}
+	copied := *farm
+	m.farms[farm.ID] = &copied
+
+	// Grant the creator the farm
+	if m.memberOnly && m.memberRepo != nil {
+		return m.memberRepo.Grant(&model.FarmMember{FarmID: farm.ID, Subject: m.subject, CreatedAt: time.Now().UTC()})
+	}
 	return nil
 }
 
 func (m *MockFarmRepository) GetByName(name string) (*model.Farm, error) {
 	for _, farm := range m.farms {
-		if farm.Name == name {
-			return farm, nil
+		if farm.Name == name && farm.OrganizationID == m.organizationId {
+			copied := *farm
+			return &copied, nil
 		}
 	}
-	return nil, nil
+	return nil, gorm.ErrRecordNotFound
 }
 
-func (m *MockFarmRepository) Get() ([]model.Farm, error) {
+// live returns the farms in scope that are not deleted, in no order
+func (m *MockFarmRepository) live() []model.Farm {
 	farms := make([]model.Farm, 0, len(m.farms))
 	for _, farm := range m.farms {
+		if farm.DeletedAt != nil || farm.OrganizationID != m.organizationId || !m.reaches(farm.ID) {
+			continue
+		}
 		farms = append(farms, *farm)
 	}
-	return farms, nil
+	return farms
+}
+
+func (m *MockFarmRepository) List(query model.ListQuery) ([]model.Farm, int64, error) {
+	farms := make([]model.Farm, 0, len(m.farms))
+	for _, farm := range m.farms {
+		if (farm.DeletedAt != nil && !query.IncludeDeleted) || !nameContains(farm.Name, query.NameContains) || farm.OrganizationID != m.organizationId || !m.reaches(farm.ID) {
+			continue
+		}
+		farms = append(farms, *farm)
+	}
+	page := paginate(farms, query,
+		func(farm model.Farm) int { return farm.ID },
+		func(farm model.Farm) string { return farm.Name },
+	)
+	return page, int64(len(farms)), nil
+}
+
+func (m *MockFarmRepository) Export(each func(model.Farm) error) error {
+	farms := m.live()
+	sortFarms(farms)
+	for _, farm := range farms {
+		if err := each(farm); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (m *MockFarmRepository) GetById(id int) (*model.Farm, error) {
-	farm, ok := m.farms[id]
-	if !ok {
-		return nil, nil
+	farm, ok := m.get(id)
+	if !ok || farm.DeletedAt != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *farm
+	return &copied, nil
+}
+
+func (m *MockFarmRepository) GetDeletedById(id int) (*model.Farm, error) {
+	farm, ok := m.get(id)
+	if !ok || farm.DeletedAt == nil {
+		return nil, gorm.ErrRecordNotFound
 	}
-	return farm, nil
+	copied := *farm
+	return &copied, nil
 }
 
+// Update only changes the name, leaving a deleted farm deleted
 func (m *MockFarmRepository) Update(id int, farm *model.Farm) error {
-	existingFarm, ok := m.farms[id]
-	if !ok {
-		return errors.New("Farm not found")
+	existingFarm, ok := m.get(id)
+	if !ok || farm.Version != existingFarm.Version {
+		return gormRepository.ErrVersionConflict
+	}
+	if m.nameTaken(farm.Name, id) {
+		return errDuplicateName
+	}
+	after := *existingFarm
+	after.Name = farm.Name
+	after.Version++
+	if err := m.audit(model.AuditUpdate, existingFarm, after); err != nil {
+		return err
 	}
 
-	farm.ID = existingFarm.ID
-	m.farms[id] = farm
+	existingFarm.Name = farm.Name
+	existingFarm.Version++
+	farm.Version++
 	return nil
 }
 
+// Delete refuses a farm within reach that ponds not deleted still reference,
+// in any scope, or that is no longer at farm.Version
 func (m *MockFarmRepository) Delete(farm *model.Farm) error {
-	delete(m.farms, farm.ID)
+	existingFarm, ok := m.get(farm.ID)
+	if ok && m.pondRepo != nil {
+		for _, pond := range m.pondRepo.ponds {
+			if pond.FarmID == farm.ID && pond.DeletedAt == nil {
+				return gormRepository.ErrFarmHasPonds
+			}
+		}
+	}
+	if !ok || farm.Version != existingFarm.Version {
+		return gormRepository.ErrVersionConflict
+	}
+
+	deletedAt := time.Now().UTC()
+	after := *existingFarm
+	after.DeletedAt = &deletedAt
+	after.Version++
+	if err := m.audit(model.AuditDelete, existingFarm, after); err != nil {
+		return err
+	}
+	storedDeletedAt := deletedAt
+	existingFarm.DeletedAt = &storedDeletedAt
+	existingFarm.Version++
+	farm.DeletedAt = &deletedAt
+	farm.Version++
+	return nil
+}
+
+// Restore refuses a farm no longer at farm.Version
+func (m *MockFarmRepository) Restore(farm *model.Farm) error {
+	existingFarm, ok := m.get(farm.ID)
+	if !ok || farm.Version != existingFarm.Version {
+		return gormRepository.ErrVersionConflict
+	}
+	after := *existingFarm
+	after.DeletedAt = nil
+	after.Version++
+	if err := m.audit(model.AuditRestore, existingFarm, after); err != nil {
+		return err
+	}
+
+	existingFarm.DeletedAt = nil
+	existingFarm.Version++
+	farm.DeletedAt = nil
+	farm.Version++
+	return nil
+}
+
+// DeleteWithPonds restores a copy of the farms, ponds and audits taken
+// beforehand when any deletion fails, like the transaction of the database
+func (m *MockFarmRepository) DeleteWithPonds(farm *model.Farm) error {
+	if existingFarm, ok := m.get(farm.ID); !ok || farm.Version != existingFarm.Version {
+		return gormRepository.ErrVersionConflict
+	}
+	rollback := m.transaction()
+	if m.pondRepo != nil {
+		pondRepo := m.ponds()
+		ponds, _ := pondRepo.GetByFarmId(farm.ID)
+		for i := range ponds {
+			if err := pondRepo.Delete(&ponds[i]); err != nil {
+				return rollback(err)
+			}
+		}
+	}
+	if err := m.Delete(farm); err != nil {
+		return rollback(err)
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+func (m *MockFarmRepository) GetByNames(names []string) ([]model.Farm, error) {
+	farms := make([]model.Farm, 0)
+	for _, name := range names {
+		if farm, _ := m.GetByName(name); farm != nil {
+			farms = append(farms, *farm)
+		}
+	}
+	return farms, nil
+}
+
+func (m *MockFarmRepository) GetByIds(ids []int) ([]model.Farm, error) {
+	farms := make([]model.Farm, 0)
+	for _, id := range ids {
+		if farm, _ := m.GetById(id); farm != nil {
+			farms = append(farms, *farm)
+		}
+	}
+	sortFarms(farms)
+	return farms, nil
+}
+
+// transaction copies the farms and the linked ponds, members and audits,
+// returning a function restoring the copy to roll back a failed transaction
+func (m *MockFarmRepository) transaction() func(err error) error {
+	farmSnapshot := m.snapshot()
+	var pondSnapshot map[int]*model.Pond
+	if m.pondRepo != nil {
+		pondSnapshot = m.pondRepo.snapshot()
+	}
+	var memberSnapshot []*model.FarmMember
+	if m.memberRepo != nil {
+		memberSnapshot = m.memberRepo.snapshot()
+	}
+	var auditCount int
+	if m.auditRepo != nil {
+		auditCount = len(m.auditRepo.audits)
+	}
+	return func(err error) error {
+		m.restore(farmSnapshot)
+		if m.pondRepo != nil {
+			m.pondRepo.restore(pondSnapshot)
+		}
+		if m.memberRepo != nil {
+			m.memberRepo.members = memberSnapshot
+		}
+		if m.auditRepo != nil {
+			m.auditRepo.restore(auditCount)
+		}
+		return err
+	}
+}
+
+// CreateWithPonds restores a copy of the farms, ponds, members and audits
+// taken beforehand when any of them fails, like the transaction of the
+// database
+func (m *MockFarmRepository) CreateWithPonds(farms []*model.Farm, ponds []model.ImportPond) error {
+	rollback := m.transaction()
+	var pondRepo *MockPondRepository
+	if m.pondRepo != nil {
+		pondRepo = m.ponds()
+	}
+
+	for _, farm := range farms {
+		if err := m.Create(farm); err != nil {
+			return rollback(err)
+		}
+	}
+	for _, pond := range ponds {
+		pond.Pond.FarmID = pond.Farm.ID
+		if pondRepo != nil {
+			if err := pondRepo.Create(pond.Pond); err != nil {
+				return rollback(err)
+			}
+		}
+	}
+	return nil
+}
+
+// Bulk restores a copy of the farms, members and audits taken beforehand to
+// roll back an atomic batch
+func (m *MockFarmRepository) Bulk(items []model.FarmBulkItem, atomic bool) ([]error, error) {
+	rollback := m.transaction()
+
+	errs := make([]error, len(items))
+	for i := range items {
+		item := &items[i]
+		switch item.Operation {
+		case model.BulkCreate:
+			errs[i] = m.Create(&item.Farm)
+		case model.BulkUpdate:
+			errs[i] = m.Update(item.ID, &item.Farm)
+		default:
+			errs[i] = m.Delete(&item.Farm)
+		}
+		if errs[i] != nil && atomic {
+			return errs, rollback(errs[i])
+		}
+	}
+	return errs, nil
+}
+
+func sortFarms(farms []model.Farm) {
+	sort.Slice(farms, func(i, j int) bool {
+		return farms[i].ID < farms[j].ID
+	})
+}
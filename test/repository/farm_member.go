@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
+)
+
+// MockFarmMemberRepository is a mock implementation of the FarmMemberRepository
+// interface. Once linked to a MockFarmRepository, members must reference one of
+// its farms.
+type MockFarmMemberRepository struct {
+	members  []*model.FarmMember
+	farmRepo *MockFarmRepository
+}
+
+func NewMockFarmMemberRepository() *MockFarmMemberRepository {
+	return &MockFarmMemberRepository{
+		members: make([]*model.FarmMember, 0),
+	}
+}
+
+// isMember reports whether subject is a member of the farm with farmId
+func (m *MockFarmMemberRepository) isMember(farmId int, subject string) bool {
+	member, _ := m.Get(farmId, subject)
+	return member != nil
+}
+
+// snapshot copies the members, to roll back a failed transaction
+func (m *MockFarmMemberRepository) snapshot() []*model.FarmMember {
+	return append([]*model.FarmMember(nil), m.members...)
+}
+
+func (m *MockFarmMemberRepository) Grant(member *model.FarmMember) error {
+	if m.isMember(member.FarmID, member.Subject) {
+		return errDuplicateMember
+	}
+	if m.farmRepo != nil {
+		if _, ok := m.farmRepo.farms[member.FarmID]; !ok {
+			return errMissingFarm
+		}
+	}
+	copied := *member
+	m.members = append(m.members, &copied)
+	return nil
+}
+
+func (m *MockFarmMemberRepository) Revoke(farmId int, subject string) error {
+	for i, member := range m.members {
+		if member.FarmID == farmId && member.Subject == subject {
+			m.members = append(m.members[:i], m.members[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *MockFarmMemberRepository) Get(farmId int, subject string) (*model.FarmMember, error) {
+	for _, member := range m.members {
+		if member.FarmID == farmId && member.Subject == subject {
+			copied := *member
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockFarmMemberRepository) GetByFarmId(farmId int) ([]model.FarmMember, error) {
+	members := make([]model.FarmMember, 0)
+	for _, member := range m.members {
+		if member.FarmID == farmId {
+			members = append(members, *member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Subject < members[j].Subject
+	})
+	return members, nil
+}
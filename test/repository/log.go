@@ -1,49 +1,201 @@
 package repository
 
 import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	gormRepository "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
 )
 
-// MockLogRepository is a mock implementation of the LogRepository interface
+// MockLogRepository is a mock implementation of the LogRepository interface.
+// It starts in the default organization, and InOrganization returns a view of
+// the same logs scoped to another organization.
 type MockLogRepository struct {
-	logs []*model.Log
+	store          *mockLogStore
+	organizationId int
+}
+
+// mockLogStore holds the logs and errors shared by every view
+type mockLogStore struct {
+	logs          []*model.Log
+	createErr     error
+	statisticsErr error
 }
 
 func NewMockLogRepository() *MockLogRepository {
 	return &MockLogRepository{
-		logs: make([]*model.Log, 0),
+		store: &mockLogStore{
+			logs: make([]*model.Log, 0),
+		},
+		organizationId: model.DefaultOrganizationID,
+	}
+}
+
+func (m *MockLogRepository) InOrganization(organizationId int) gormRepository.LogRepository {
+	return &MockLogRepository{
+		store:          m.store,
+		organizationId: organizationId,
 	}
 }
 
+// SetCreateError makes every subsequent Create call fail with err
+func (m *MockLogRepository) SetCreateError(err error) {
+	m.store.createErr = err
+}
+
+// SetStatisticsError makes every subsequent GetStatistics call fail with err
+func (m *MockLogRepository) SetStatisticsError(err error) {
+	m.store.statisticsErr = err
+}
+
 func (m *MockLogRepository) Create(log *model.Log) error {
-	m.logs = append(m.logs, log)
+	if m.store.createErr != nil {
+		return m.store.createErr
+	}
+	if log.ID == 0 {
+		for _, existingLog := range m.store.logs {
+			if existingLog.ID > log.ID {
+				log.ID = existingLog.ID
+			}
+		}
+		log.ID++
+	}
+	log.OrganizationID = nil
+	if m.organizationId != 0 {
+		organizationId := m.organizationId
+		log.OrganizationID = &organizationId
+	}
+	copied := *log
+	m.store.logs = append(m.store.logs, &copied)
 	return nil
 }
 
-func (m *MockLogRepository) GetDistinctEndpoints() ([]string, error) {
-	endpoints := make([]string, 0)
-	visitedEndpoints := make(map[string]bool)
-	for _, log := range m.logs {
-		if !visitedEndpoints[log.Endpoint] {
-			endpoints = append(endpoints, log.Endpoint)
-			visitedEndpoints[log.Endpoint] = true
+// Logs returns every log recorded so far, in any organization or none
+func (m *MockLogRepository) Logs() []*model.Log {
+	return m.store.logs
+}
+
+// logs returns the logs of the organization the repository is in
+func (m *MockLogRepository) logs() []*model.Log {
+	logs := make([]*model.Log, 0)
+	for _, log := range m.store.logs {
+		if log.OrganizationID != nil && *log.OrganizationID == m.organizationId {
+			logs = append(logs, log)
 		}
 	}
-	return endpoints, nil
+	return logs
 }
 
-func (m *MockLogRepository) GetEndpointStatistics(endpoint string) (*model.EndpointStatistics, error) {
-	count := 0
-	uniqueUserAgents := make(map[string]bool)
-	for _, log := range m.logs {
-		if log.Endpoint == endpoint {
-			count++
-			uniqueUserAgents[log.UserAgent] = true
+func (m *MockLogRepository) GetStatistics() (model.Statistics, error) {
+	if m.store.statisticsErr != nil {
+		return nil, m.store.statisticsErr
+	}
+
+	endpoints := make(map[string][]*model.Log)
+	for _, log := range m.logs() {
+		endpoints[log.Endpoint] = append(endpoints[log.Endpoint], log)
+	}
+
+	statistics := make(model.Statistics)
+	for endpoint, logs := range endpoints {
+		statistics[endpoint] = aggregateLogs(logs)
+	}
+	return statistics, nil
+}
+
+func (m *MockLogRepository) GetBucketStatistics(from time.Time, to time.Time, bucket model.StatisticsBucket) ([]model.BucketStatistics, error) {
+	if !bucket.IsValid() {
+		return nil, errors.New("Invalid statistics bucket")
+	}
+
+	buckets := make(map[time.Time]map[string][]*model.Log)
+	for _, log := range m.logs() {
+		if (!from.IsZero() && log.CreatedAt.Before(from)) || (!to.IsZero() && !log.CreatedAt.Before(to)) {
+			continue
+		}
+		bucketTime := bucket.Truncate(log.CreatedAt)
+		if buckets[bucketTime] == nil {
+			buckets[bucketTime] = make(map[string][]*model.Log)
+		}
+		buckets[bucketTime][log.Endpoint] = append(buckets[bucketTime][log.Endpoint], log)
+	}
+
+	bucketStatistics := make([]model.BucketStatistics, 0, len(buckets))
+	for bucketTime, endpoints := range buckets {
+		statistics := make(model.Statistics)
+		for endpoint, logs := range endpoints {
+			statistics[endpoint] = aggregateLogs(logs)
 		}
+		bucketStatistics = append(bucketStatistics, model.BucketStatistics{
+			Bucket:    bucketTime,
+			Endpoints: statistics,
+		})
 	}
+	sort.Slice(bucketStatistics, func(i, j int) bool {
+		return bucketStatistics[i].Bucket.Before(bucketStatistics[j].Bucket)
+	})
+	return bucketStatistics, nil
+}
+
+// aggregateLogs mirrors the LogRepository statistics query, including the
+// nearest-rank latency percentiles
+func aggregateLogs(logs []*model.Log) *model.EndpointStatistics {
 	statistics := &model.EndpointStatistics{
-		Count:             int64(count),
-		UniqueUserAgent:   int64(len(uniqueUserAgents)),
+		Count: int64(len(logs)),
 	}
-	return statistics, nil
-}
\ No newline at end of file
+	uniqueUserAgents := make(map[string]bool)
+	latencies := make([]int64, 0, len(logs))
+	for _, log := range logs {
+		uniqueUserAgents[log.UserAgent] = true
+		latencies = append(latencies, log.LatencyMicros)
+		switch {
+		case log.StatusCode >= 200 && log.StatusCode < 300:
+			statistics.Status2xx++
+		case log.StatusCode >= 400 && log.StatusCode < 500:
+			statistics.Status4xx++
+		case log.StatusCode >= 500:
+			statistics.Status5xx++
+		}
+	}
+	statistics.UniqueUserAgent = int64(len(uniqueUserAgents))
+	statistics.SetErrorRate()
+
+	if len(latencies) == 0 {
+		return statistics
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	percentile := func(p float64) int64 {
+		rank := int(math.Ceil(p * float64(len(latencies))))
+		if rank < 1 {
+			rank = 1
+		}
+		return latencies[rank-1]
+	}
+	statistics.LatencyMin = latencies[0]
+	statistics.LatencyMax = latencies[len(latencies)-1]
+	statistics.LatencyP50 = percentile(0.50)
+	statistics.LatencyP90 = percentile(0.90)
+	statistics.LatencyP99 = percentile(0.99)
+	return statistics
+}
+
+func (m *MockLogRepository) Export(from time.Time, to time.Time, each func(model.Log) error) error {
+	logs := m.logs()
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].ID < logs[j].ID
+	})
+	for _, log := range logs {
+		if (!from.IsZero() && log.CreatedAt.Before(from)) || (!to.IsZero() && !log.CreatedAt.Before(to)) {
+			continue
+		}
+		if err := each(*log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"gorm.io/gorm"
+)
+
+// MockOrganizationRepository is a mock implementation of the
+// OrganizationRepository interface, holding the default organization like a
+// migrated database
+type MockOrganizationRepository struct {
+	organizations []*model.Organization
+}
+
+func NewMockOrganizationRepository() *MockOrganizationRepository {
+	return &MockOrganizationRepository{
+		organizations: []*model.Organization{
+			{ID: model.DefaultOrganizationID, Name: "default", CreatedAt: time.Now().UTC()},
+		},
+	}
+}
+
+func (m *MockOrganizationRepository) Create(organization *model.Organization) error {
+	for _, existingOrganization := range m.organizations {
+		if existingOrganization.Name == organization.Name {
+			return errDuplicateName
+		}
+	}
+	organization.ID = len(m.organizations) + 1
+	copied := *organization
+	m.organizations = append(m.organizations, &copied)
+	return nil
+}
+
+func (m *MockOrganizationRepository) List() ([]model.Organization, error) {
+	organizations := make([]model.Organization, 0, len(m.organizations))
+	for _, organization := range m.organizations {
+		organizations = append(organizations, *organization)
+	}
+	return organizations, nil
+}
+
+func (m *MockOrganizationRepository) GetByName(name string) (*model.Organization, error) {
+	for _, organization := range m.organizations {
+		if organization.Name == name {
+			copied := *organization
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
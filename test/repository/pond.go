@@ -1,65 +1,397 @@
 package repository
 
 import (
-	"errors"
-	
+	"sort"
+	"time"
+
 	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	gormRepository "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"gorm.io/gorm"
 )
 
-// MockPondRepository is a mock implementation of the PondRepository interface
+// MockPondRepository is a mock implementation of the PondRepository interface.
+// Once linked to a MockFarmRepository, ponds must reference one of its farms
+// in the same organization. Like MockFarmRepository it starts in the default
+// organization, and ForMember reaches the farms granted in the
+// MockFarmMemberRepository linked to that MockFarmRepository. AuditedBy
+// stores audits in the linked MockAuditRepository.
 type MockPondRepository struct {
-	ponds      map[int]*model.Pond
+	ponds          map[int]*model.Pond
+	farmRepo       *MockFarmRepository
+	auditRepo      *MockAuditRepository
+	organizationId int
+	memberOnly     bool
+	subject        string
+	actor          string
 }
 
 func NewMockPondRepository() *MockPondRepository {
 	return &MockPondRepository{
-		ponds:      make(map[int]*model.Pond),
+		ponds:          make(map[int]*model.Pond),
+		organizationId: model.DefaultOrganizationID,
+	}
+}
+
+func (m *MockPondRepository) InOrganization(organizationId int) gormRepository.PondRepository {
+	return m.inOrganization(organizationId)
+}
+
+func (m *MockPondRepository) inOrganization(organizationId int) *MockPondRepository {
+	return &MockPondRepository{
+		ponds:          m.ponds,
+		farmRepo:       m.farmRepo,
+		auditRepo:      m.auditRepo,
+		organizationId: organizationId,
+	}
+}
+
+func (m *MockPondRepository) ForMember(subject string) gormRepository.PondRepository {
+	scoped := m.inOrganization(m.organizationId)
+	scoped.memberOnly = true
+	scoped.subject = subject
+	return scoped
+}
+
+func (m *MockPondRepository) AuditedBy(actor string) gormRepository.PondRepository {
+	audited := *m
+	audited.actor = actor
+	return &audited
+}
+
+// LinkAuditRepository lets AuditedBy store audits in auditRepo
+func (m *MockPondRepository) LinkAuditRepository(auditRepo *MockAuditRepository) {
+	m.auditRepo = auditRepo
+}
+
+// audit stores the audit of a mutation turning before into after, when
+// audited, before the mutation stores after like MockFarmRepository.audit
+func (m *MockPondRepository) audit(operation string, before *model.Pond, after model.Pond) error {
+	if m.actor == "" || m.auditRepo == nil {
+		return nil
+	}
+	if before == nil {
+		return m.auditRepo.record(m.actor, model.AuditEntityPond, after.ID, operation, nil, after)
+	}
+	return m.auditRepo.record(m.actor, model.AuditEntityPond, after.ID, operation, *before, after)
+}
+
+// reaches reports whether the ponds of the farm with farmId are within reach
+// of the member, if any
+func (m *MockPondRepository) reaches(farmId int) bool {
+	if !m.memberOnly {
+		return true
+	}
+	return m.farmRepo != nil && m.farmRepo.memberRepo != nil && m.farmRepo.memberRepo.isMember(farmId, m.subject)
+}
+
+// get returns the stored pond with id if it belongs to the organization and is
+// within reach, deleted or not
+func (m *MockPondRepository) get(id int) (*model.Pond, bool) {
+	pond, ok := m.ponds[id]
+	if !ok || pond.OrganizationID != m.organizationId || !m.reaches(pond.FarmID) {
+		return nil, false
+	}
+	return pond, true
+}
+
+// nameTaken reports whether a pond of the organization other than id has
+// name, deleted or not
+func (m *MockPondRepository) nameTaken(name string, id int) bool {
+	for _, pond := range m.ponds {
+		if pond.Name == name && pond.ID != id && pond.OrganizationID == m.organizationId {
+			return true
+		}
+	}
+	return false
+}
+
+// farmMissing reports whether farmId references no farm of the organization,
+// deleted or not
+func (m *MockPondRepository) farmMissing(farmId int) bool {
+	if m.farmRepo == nil {
+		return false
+	}
+	_, ok := m.farmRepo.inOrganization(m.organizationId).get(farmId)
+	return !ok
+}
+
+// snapshot copies the ponds, to roll back a failed transaction
+func (m *MockPondRepository) snapshot() map[int]*model.Pond {
+	snapshot := make(map[int]*model.Pond, len(m.ponds))
+	for id, pond := range m.ponds {
+		copied := *pond
+		snapshot[id] = &copied
+	}
+	return snapshot
+}
+
+// restore puts back the ponds of a snapshot in place, as scoped views share
+// the map
+func (m *MockPondRepository) restore(snapshot map[int]*model.Pond) {
+	for id := range m.ponds {
+		delete(m.ponds, id)
+	}
+	for id, pond := range snapshot {
+		m.ponds[id] = pond
 	}
 }
 
 func (m *MockPondRepository) Create(pond *model.Pond) error {
-	pond.ID = len(m.ponds) + 1
-	m.ponds[pond.ID] = pond
+	operation := model.AuditCreate
+	if pond.ID != 0 {
+		operation = model.AuditUpsert
+	}
+	if existing, ok := m.ponds[pond.ID]; ok {
+		if existing.OrganizationID != m.organizationId || !m.reaches(existing.FarmID) {
+			return gormRepository.ErrIdTaken
+		}
+		return errDuplicateId
+	}
+	if m.nameTaken(pond.Name, 0) {
+		return errDuplicateName
+	}
+	if m.farmMissing(pond.FarmID) {
+		return errMissingFarm
+	}
+
+	// Keep a preset id, as PUT creates the pond at the id in the path, and
+	// never reuse the id of a deleted pond, ids being shared by organizations
+	if pond.ID == 0 {
+		for id := range m.ponds {
+			if id > pond.ID {
+				pond.ID = id
+			}
+		}
+		pond.ID++
+	}
+	if pond.Version == 0 {
+		pond.Version = 1
+	}
+	pond.OrganizationID = m.organizationId
+	if err := m.audit(operation, nil, *pond); err != nil {
+		return err
+	}
+	copied := *pond
+	m.ponds[pond.ID] = &copied
 	return nil
 }
 
 func (m *MockPondRepository) GetByName(name string) (*model.Pond, error) {
 	for _, pond := range m.ponds {
-		if pond.Name == name {
-			return pond, nil
+		if pond.Name == name && pond.OrganizationID == m.organizationId {
+			copied := *pond
+			return &copied, nil
 		}
 	}
-	return nil, nil
+	return nil, gorm.ErrRecordNotFound
 }
 
-func (m *MockPondRepository) Get() ([]model.Pond, error) {
+// live returns the ponds in scope that are not deleted, in no order
+func (m *MockPondRepository) live() []model.Pond {
 	ponds := make([]model.Pond, 0, len(m.ponds))
 	for _, pond := range m.ponds {
+		if pond.DeletedAt != nil || pond.OrganizationID != m.organizationId || !m.reaches(pond.FarmID) {
+			continue
+		}
 		ponds = append(ponds, *pond)
 	}
-	return ponds, nil
+	return ponds
+}
+
+func (m *MockPondRepository) List(query model.ListQuery) ([]model.Pond, int64, error) {
+	ponds := make([]model.Pond, 0, len(m.ponds))
+	for _, pond := range m.ponds {
+		if (pond.DeletedAt != nil && !query.IncludeDeleted) || !nameContains(pond.Name, query.NameContains) || (query.FarmID != 0 && pond.FarmID != query.FarmID) || pond.OrganizationID != m.organizationId || !m.reaches(pond.FarmID) {
+			continue
+		}
+		ponds = append(ponds, *pond)
+	}
+	page := paginate(ponds, query,
+		func(pond model.Pond) int { return pond.ID },
+		func(pond model.Pond) string { return pond.Name },
+	)
+	return page, int64(len(ponds)), nil
+}
+
+func (m *MockPondRepository) Export(each func(model.PondExport) error) error {
+	ponds := m.live()
+	sortPonds(ponds)
+	for _, pond := range ponds {
+		export := model.PondExport{Pond: pond}
+		if m.farmRepo != nil {
+			if farm, ok := m.farmRepo.farms[pond.FarmID]; ok {
+				export.FarmName = farm.Name
+			}
+		}
+		if err := each(export); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (m *MockPondRepository) GetById(id int) (*model.Pond, error) {
-	pond, ok := m.ponds[id]
-	if !ok {
-		return nil, nil
+	pond, ok := m.get(id)
+	if !ok || pond.DeletedAt != nil {
+		return nil, gorm.ErrRecordNotFound
 	}
-	return pond, nil
+	copied := *pond
+	return &copied, nil
 }
 
+func (m *MockPondRepository) GetDeletedById(id int) (*model.Pond, error) {
+	pond, ok := m.get(id)
+	if !ok || pond.DeletedAt == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *pond
+	return &copied, nil
+}
+
+func (m *MockPondRepository) GetByFarmId(farmId int) ([]model.Pond, error) {
+	return m.GetByFarmIds([]int{farmId})
+}
+
+// Update only changes the name and farm, leaving a deleted pond deleted
 func (m *MockPondRepository) Update(id int, pond *model.Pond) error {
-	existingPond, ok := m.ponds[id]
-	if !ok {
-		return errors.New("Pond not found")
+	existingPond, ok := m.get(id)
+	if !ok || pond.Version != existingPond.Version {
+		return gormRepository.ErrVersionConflict
+	}
+	if m.nameTaken(pond.Name, id) {
+		return errDuplicateName
+	}
+	if m.farmMissing(pond.FarmID) {
+		return errMissingFarm
+	}
+	after := *existingPond
+	after.Name = pond.Name
+	after.FarmID = pond.FarmID
+	after.Version++
+	if err := m.audit(model.AuditUpdate, existingPond, after); err != nil {
+		return err
 	}
 
-	pond.ID = existingPond.ID
-	m.ponds[id] = pond
+	existingPond.Name = pond.Name
+	existingPond.FarmID = pond.FarmID
+	existingPond.Version++
+	pond.Version++
 	return nil
 }
 
+// Delete refuses a pond no longer at pond.Version
 func (m *MockPondRepository) Delete(pond *model.Pond) error {
-	delete(m.ponds, pond.ID)
+	existingPond, ok := m.get(pond.ID)
+	if !ok || pond.Version != existingPond.Version {
+		return gormRepository.ErrVersionConflict
+	}
+
+	deletedAt := time.Now().UTC()
+	after := *existingPond
+	after.DeletedAt = &deletedAt
+	after.Version++
+	if err := m.audit(model.AuditDelete, existingPond, after); err != nil {
+		return err
+	}
+	storedDeletedAt := deletedAt
+	existingPond.DeletedAt = &storedDeletedAt
+	existingPond.Version++
+	pond.DeletedAt = &deletedAt
+	pond.Version++
+	return nil
+}
+
+// Restore refuses a pond no longer at pond.Version
+func (m *MockPondRepository) Restore(pond *model.Pond) error {
+	existingPond, ok := m.get(pond.ID)
+	if !ok || pond.Version != existingPond.Version {
+		return gormRepository.ErrVersionConflict
+	}
+	after := *existingPond
+	after.DeletedAt = nil
+	after.Version++
+	if err := m.audit(model.AuditRestore, existingPond, after); err != nil {
+		return err
+	}
+
+	existingPond.DeletedAt = nil
+	existingPond.Version++
+	pond.DeletedAt = nil
+	pond.Version++
 	return nil
-}
\ No newline at end of file
+}
+
+func (m *MockPondRepository) GetByNames(names []string) ([]model.Pond, error) {
+	ponds := make([]model.Pond, 0)
+	for _, name := range names {
+		if pond, _ := m.GetByName(name); pond != nil {
+			ponds = append(ponds, *pond)
+		}
+	}
+	return ponds, nil
+}
+
+func (m *MockPondRepository) GetByIds(ids []int) ([]model.Pond, error) {
+	ponds := make([]model.Pond, 0)
+	for _, id := range ids {
+		if pond, _ := m.GetById(id); pond != nil {
+			ponds = append(ponds, *pond)
+		}
+	}
+	sortPonds(ponds)
+	return ponds, nil
+}
+
+func (m *MockPondRepository) GetByFarmIds(farmIds []int) ([]model.Pond, error) {
+	ponds := make([]model.Pond, 0)
+	for _, pond := range m.ponds {
+		if pond.DeletedAt != nil || pond.OrganizationID != m.organizationId || !m.reaches(pond.FarmID) {
+			continue
+		}
+		for _, farmId := range farmIds {
+			if pond.FarmID == farmId {
+				ponds = append(ponds, *pond)
+				break
+			}
+		}
+	}
+	sortPonds(ponds)
+	return ponds, nil
+}
+
+// Bulk restores a copy of the ponds and audits taken beforehand to roll back
+// an atomic batch
+func (m *MockPondRepository) Bulk(items []model.PondBulkItem, atomic bool) ([]error, error) {
+	snapshot := m.snapshot()
+	var auditCount int
+	if m.auditRepo != nil {
+		auditCount = len(m.auditRepo.audits)
+	}
+
+	errs := make([]error, len(items))
+	for i := range items {
+		item := &items[i]
+		switch item.Operation {
+		case model.BulkCreate:
+			errs[i] = m.Create(&item.Pond)
+		case model.BulkUpdate:
+			errs[i] = m.Update(item.ID, &item.Pond)
+		default:
+			errs[i] = m.Delete(&item.Pond)
+		}
+		if errs[i] != nil && atomic {
+			m.restore(snapshot)
+			if m.auditRepo != nil {
+				m.auditRepo.restore(auditCount)
+			}
+			return errs, errs[i]
+		}
+	}
+	return errs, nil
+}
+
+func sortPonds(ponds []model.Pond) {
+	sort.Slice(ponds, func(i, j int) bool {
+		return ponds[i].ID < ponds[j].ID
+	})
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+)
+
+// paginate sorts items as the ListQuery asks, with id as the tie breaker,
+// and returns the requested page
+func paginate[T any](items []T, query model.ListQuery, id func(T) int, name func(T) string) []T {
+	column, desc := query.SortColumn()
+	sort.Slice(items, func(i, j int) bool {
+		if column == "name" && name(items[i]) != name(items[j]) {
+			return (name(items[i]) < name(items[j])) != desc
+		}
+		if column == "id" && desc {
+			return id(items[i]) > id(items[j])
+		}
+		return id(items[i]) < id(items[j])
+	})
+
+	start := query.Offset()
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + query.PageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// Errors standing in for the constraint violations of the database
+var (
+	errDuplicateId     = errors.New("duplicate primary key")
+	errDuplicateName   = errors.New("duplicate name")
+	errDuplicateHash   = errors.New("duplicate key hash")
+	errDuplicateMember = errors.New("duplicate farm member")
+	errMissingFarm     = errors.New("foreign key constraint failed: farm_id")
+)
+
+// nameContains matches value anywhere in name case insensitively, like the
+// LIKE filter of the database
+func nameContains(name string, value string) bool {
+	return strings.Contains(strings.ToLower(name), strings.ToLower(value))
+}
@@ -0,0 +1,726 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	gormRepository "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// repositories holds one implementation of every repository, sharing a single
+// store
+type repositories struct {
+	farm         gormRepository.FarmRepository
+	pond         gormRepository.PondRepository
+	log          gormRepository.LogRepository
+	apiKey       gormRepository.ApiKeyRepository
+	organization gormRepository.OrganizationRepository
+	farmMember   gormRepository.FarmMemberRepository
+	audit        gormRepository.AuditRepository
+
+	// failAudits makes every following audit write fail
+	failAudits func(t *testing.T)
+}
+
+// forEachRepository runs the same contract test against the mocks the handler
+// tests use and against the gorm repositories on every database of
+// forEachDatabase, so the mocks cannot drift from the real storage. The farm,
+// pond and log repositories are scoped to the default organization.
+func forEachRepository(t *testing.T, test func(t *testing.T, repos repositories)) {
+	t.Run("mock", func(t *testing.T) {
+		farmRepo := repository.NewMockFarmRepository()
+		pondRepo := repository.NewMockPondRepository()
+		farmMemberRepo := repository.NewMockFarmMemberRepository()
+		auditRepo := repository.NewMockAuditRepository()
+		farmRepo.LinkPondRepository(pondRepo)
+		farmRepo.LinkFarmMemberRepository(farmMemberRepo)
+		farmRepo.LinkAuditRepository(auditRepo)
+		pondRepo.LinkAuditRepository(auditRepo)
+		test(t, repositories{
+			farm:         farmRepo.InOrganization(model.DefaultOrganizationID),
+			pond:         pondRepo.InOrganization(model.DefaultOrganizationID),
+			log:          repository.NewMockLogRepository().InOrganization(model.DefaultOrganizationID),
+			apiKey:       repository.NewMockApiKeyRepository(),
+			organization: repository.NewMockOrganizationRepository(),
+			farmMember:   farmMemberRepo,
+			audit:        auditRepo,
+			failAudits: func(t *testing.T) {
+				auditRepo.Fail(errors.New("audits unavailable"))
+			},
+		})
+	})
+
+	forEachDatabase(t, func(t *testing.T, gormDB *gorm.DB) {
+		test(t, repositories{
+			farm:         gormRepository.NewFarmRepository(gormDB).InOrganization(model.DefaultOrganizationID),
+			pond:         gormRepository.NewPondRepository(gormDB).InOrganization(model.DefaultOrganizationID),
+			log:          gormRepository.NewLogRepository(gormDB).InOrganization(model.DefaultOrganizationID),
+			apiKey:       gormRepository.NewApiKeyRepository(gormDB),
+			organization: gormRepository.NewOrganizationRepository(gormDB),
+			farmMember:   gormRepository.NewFarmMemberRepository(gormDB),
+			audit:        gormRepository.NewAuditRepository(gormDB),
+			failAudits: func(t *testing.T) {
+				// Move the audits table out of the way until the test ends
+				require.NoError(t, gormDB.Exec("ALTER TABLE audits RENAME TO unavailable_audits").Error)
+				t.Cleanup(func() {
+					gormDB.Exec("ALTER TABLE unavailable_audits RENAME TO audits")
+				})
+			},
+		})
+	})
+}
+
+// createFarms creates a farm for each name, failing the test on error
+func createFarms(t *testing.T, farmRepo gormRepository.FarmRepository, names ...string) []*model.Farm {
+	farms := make([]*model.Farm, len(names))
+	for i, name := range names {
+		farms[i] = &model.Farm{Name: name}
+		require.NoError(t, farmRepo.Create(farms[i]))
+	}
+	return farms
+}
+
+// listFarms lists the farms of farmRepo that are not deleted, ordered by id,
+// failing the test on error
+func listFarms(t *testing.T, farmRepo gormRepository.FarmRepository) []model.Farm {
+	farms, _, err := farmRepo.List(model.ListQuery{Page: 1, PageSize: model.MaxPageSize})
+	require.NoError(t, err)
+	return farms
+}
+
+// listPonds lists the ponds of pondRepo that are not deleted, ordered by id,
+// failing the test on error
+func listPonds(t *testing.T, pondRepo gormRepository.PondRepository) []model.Pond {
+	ponds, _, err := pondRepo.List(model.ListQuery{Page: 1, PageSize: model.MaxPageSize})
+	require.NoError(t, err)
+	return ponds
+}
+
+func TestFarmRepository_Create(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		// Ids increase and are never reused after a delete
+		farms := createFarms(t, repos.farm, "Farm 1", "Farm 2")
+		assert.Equal(t, 1, farms[0].ID)
+		assert.Equal(t, 2, farms[1].ID)
+		assert.Equal(t, 1, farms[1].Version)
+		assert.NoError(t, repos.farm.Delete(farms[1]))
+		farm3 := &model.Farm{Name: "Farm 3"}
+		assert.NoError(t, repos.farm.Create(farm3))
+		assert.Equal(t, 3, farm3.ID)
+
+		// Ids keep increasing past an id created explicitly
+		assert.NoError(t, repos.farm.Create(&model.Farm{ID: 42, Name: "Farm 42"}))
+		farm43 := &model.Farm{Name: "Farm 43"}
+		assert.NoError(t, repos.farm.Create(farm43))
+		assert.Equal(t, 43, farm43.ID)
+
+		// Names are unique, including the names of deleted farms
+		assert.Error(t, repos.farm.Create(&model.Farm{Name: "Farm 1"}))
+		assert.Error(t, repos.farm.Create(&model.Farm{Name: "Farm 2"}))
+
+		// The stored farm is a copy of the created one
+		farm3.Name = "Changed"
+		farm, err := repos.farm.GetById(3)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Farm{ID: 3, Name: "Farm 3", Version: 1, OrganizationID: model.DefaultOrganizationID}, farm)
+	})
+}
+
+func TestFarmRepository_Get(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		farms := createFarms(t, repos.farm, "Farm 1", "Farm 2", "Farm 3")
+		assert.NoError(t, repos.farm.Delete(farms[1]))
+
+		// Lookups by id skip or only return deleted farms
+		farm, err := repos.farm.GetById(1)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Farm{ID: 1, Name: "Farm 1", Version: 1, OrganizationID: model.DefaultOrganizationID}, farm)
+		farm, err = repos.farm.GetById(2)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Nil(t, farm)
+		farm, err = repos.farm.GetDeletedById(2)
+		assert.NoError(t, err)
+		assert.NotNil(t, farm.DeletedAt)
+		farm, err = repos.farm.GetDeletedById(1)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Nil(t, farm)
+		farm, err = repos.farm.GetById(42)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Nil(t, farm)
+
+		// Returned farms are copies
+		farm, _ = repos.farm.GetById(1)
+		farm.Name = "Changed"
+		farm, _ = repos.farm.GetById(1)
+		assert.Equal(t, "Farm 1", farm.Name)
+
+		// Lookups by name include deleted farms
+		farm, err = repos.farm.GetByName("Farm 2")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, farm.ID)
+		farm, err = repos.farm.GetByName("Farm 42")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Nil(t, farm)
+		byNames, err := repos.farm.GetByNames([]string{"Farm 3", "Farm 2", "Farm 42"})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Farm 2", "Farm 3"}, []string{byNames[0].Name, byNames[1].Name})
+
+		// Lookups by ids skip deleted farms and are ordered by id
+		byIds, err := repos.farm.GetByIds([]int{3, 2, 1, 42})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Farm{{ID: 1, Name: "Farm 1", Version: 1, OrganizationID: model.DefaultOrganizationID}, {ID: 3, Name: "Farm 3", Version: 1, OrganizationID: model.DefaultOrganizationID}}, byIds)
+
+		// Listing skips deleted farms
+		all := listFarms(t, repos.farm)
+		assert.Equal(t, []model.Farm{{ID: 1, Name: "Farm 1", Version: 1, OrganizationID: model.DefaultOrganizationID}, {ID: 3, Name: "Farm 3", Version: 1, OrganizationID: model.DefaultOrganizationID}}, all)
+	})
+}
+
+func TestFarmRepository_Update(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		createFarms(t, repos.farm, "Farm 1", "Farm 2")
+
+		// Update with the current version, then with a stale one
+		farm := &model.Farm{Name: "Farm 1b", Version: 1}
+		assert.NoError(t, repos.farm.Update(1, farm))
+		assert.Equal(t, 2, farm.Version)
+		err := repos.farm.Update(1, &model.Farm{Name: "Farm 1c", Version: 1})
+		assert.ErrorIs(t, err, gormRepository.ErrVersionConflict)
+		err = repos.farm.Update(42, &model.Farm{Name: "Farm 42", Version: 1})
+		assert.ErrorIs(t, err, gormRepository.ErrVersionConflict)
+		stored, _ := repos.farm.GetById(1)
+		assert.Equal(t, &model.Farm{ID: 1, Name: "Farm 1b", Version: 2, OrganizationID: model.DefaultOrganizationID}, stored)
+
+		// Names stay unique
+		assert.Error(t, repos.farm.Update(2, &model.Farm{Name: "Farm 1b", Version: 1}))
+
+		// Updating a deleted farm keeps it deleted
+		deleted, _ := repos.farm.GetById(2)
+		assert.NoError(t, repos.farm.Delete(deleted))
+		assert.NoError(t, repos.farm.Update(2, &model.Farm{Name: "Farm 2b", Version: 2}))
+		deleted, err = repos.farm.GetDeletedById(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Farm 2b", deleted.Name)
+		assert.Equal(t, 3, deleted.Version)
+	})
+}
+
+func TestFarmRepository_DeleteRestore(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		createFarms(t, repos.farm, "Farm 1")
+		pond := &model.Pond{Name: "Pond 1", FarmID: 1}
+		assert.NoError(t, repos.pond.Create(pond))
+
+		// Deleting checks the version
+		farm, _ := repos.farm.GetById(1)
+		assert.ErrorIs(t, repos.farm.DeleteWithPonds(&model.Farm{ID: 1, Version: 2}), gormRepository.ErrVersionConflict)
+		assert.ErrorIs(t, repos.pond.Delete(&model.Pond{ID: pond.ID, Version: 2}), gormRepository.ErrVersionConflict)
+		ponds, _ := repos.pond.GetByFarmId(1)
+		assert.Len(t, ponds, 1)
+
+		// Delete the farm along with its ponds
+		assert.NoError(t, repos.farm.DeleteWithPonds(farm))
+		assert.NotNil(t, farm.DeletedAt)
+		assert.Equal(t, 2, farm.Version)
+		ponds, err := repos.pond.GetByFarmId(1)
+		assert.NoError(t, err)
+		assert.Empty(t, ponds)
+		deletedPond, err := repos.pond.GetDeletedById(pond.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, deletedPond.Version)
+
+		// Restoring the farm checks the version and leaves its ponds deleted
+		assert.ErrorIs(t, repos.farm.Restore(&model.Farm{ID: 1, Version: 1}), gormRepository.ErrVersionConflict)
+		farm, _ = repos.farm.GetDeletedById(1)
+		assert.NoError(t, repos.farm.Restore(farm))
+		assert.Nil(t, farm.DeletedAt)
+		assert.Equal(t, 3, farm.Version)
+		stored, err := repos.farm.GetById(1)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Farm{ID: 1, Name: "Farm 1", Version: 3, OrganizationID: model.DefaultOrganizationID}, stored)
+		ponds, _ = repos.pond.GetByFarmId(1)
+		assert.Empty(t, ponds)
+	})
+}
+
+func TestFarmRepository_List(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		farms := createFarms(t, repos.farm, "North 2", "South 1", "North 1", "North_3")
+		assert.NoError(t, repos.farm.Delete(farms[2]))
+
+		// Filter case insensitively, sorted by name descending
+		page, total, err := repos.farm.List(model.ListQuery{Page: 1, PageSize: 1, Sort: "-name", NameContains: "north"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, []model.Farm{{ID: 4, Name: "North_3", Version: 1, OrganizationID: model.DefaultOrganizationID}}, page)
+		page, _, _ = repos.farm.List(model.ListQuery{Page: 2, PageSize: 1, Sort: "-name", NameContains: "north"})
+		assert.Equal(t, []model.Farm{{ID: 1, Name: "North 2", Version: 1, OrganizationID: model.DefaultOrganizationID}}, page)
+
+		// LIKE wildcards in the filter are matched literally
+		page, total, _ = repos.farm.List(model.ListQuery{Page: 1, PageSize: 10, NameContains: "_"})
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "North_3", page[0].Name)
+
+		// Deleted farms are only listed on request, ordered by id by default
+		page, total, _ = repos.farm.List(model.ListQuery{Page: 1, PageSize: 10, IncludeDeleted: true})
+		assert.Equal(t, int64(4), total)
+		ids := make([]int, 0)
+		for _, farm := range page {
+			ids = append(ids, farm.ID)
+		}
+		assert.Equal(t, []int{1, 2, 3, 4}, ids)
+
+		// Export streams the farms that are not deleted ordered by id
+		exported := make([]string, 0)
+		assert.NoError(t, repos.farm.Export(func(farm model.Farm) error {
+			exported = append(exported, farm.Name)
+			return nil
+		}))
+		assert.Equal(t, []string{"North 2", "South 1", "North_3"}, exported)
+	})
+}
+
+func TestFarmRepository_CreateWithPonds(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		// Ponds of new farms get their farm id once it is created
+		farm := &model.Farm{Name: "Farm 1"}
+		ponds := []model.ImportPond{
+			{Pond: &model.Pond{Name: "Pond 1"}, Farm: farm},
+			{Pond: &model.Pond{Name: "Pond 2"}, Farm: farm},
+		}
+		assert.NoError(t, repos.farm.CreateWithPonds([]*model.Farm{farm}, ponds))
+		created, err := repos.pond.GetByFarmId(farm.ID)
+		assert.NoError(t, err)
+		assert.Len(t, created, 2)
+
+		// Nothing is committed when a pond fails
+		farm = &model.Farm{Name: "Farm 2"}
+		ponds = []model.ImportPond{{Pond: &model.Pond{Name: "Pond 1"}, Farm: farm}}
+		assert.Error(t, repos.farm.CreateWithPonds([]*model.Farm{farm}, ponds))
+		_, err = repos.farm.GetByName("Farm 2")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestFarmRepository_Bulk(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		createFarms(t, repos.farm, "Farm 1")
+
+		// An atomic batch is rolled back as a whole when an item fails
+		items := []model.FarmBulkItem{
+			{Operation: model.BulkCreate, Farm: model.Farm{Name: "Farm 2"}},
+			{Operation: model.BulkUpdate, Farm: model.Farm{ID: 1, Name: "Farm 1b", Version: 1}},
+			{Operation: model.BulkCreate, Farm: model.Farm{Name: "Farm 1b"}},
+		}
+		_, err := repos.farm.Bulk(items, true)
+		assert.Error(t, err)
+		farms := listFarms(t, repos.farm)
+		assert.Equal(t, []model.Farm{{ID: 1, Name: "Farm 1", Version: 1, OrganizationID: model.DefaultOrganizationID}}, farms)
+
+		// A best-effort batch keeps the items that succeeded
+		items = []model.FarmBulkItem{
+			{Operation: model.BulkCreate, Farm: model.Farm{Name: "Farm 2"}},
+			{Operation: model.BulkCreate, Farm: model.Farm{Name: "Farm 1"}},
+			{Operation: model.BulkUpdate, Farm: model.Farm{ID: 1, Name: "Farm 1b", Version: 1}},
+		}
+		errs, err := repos.farm.Bulk(items, false)
+		assert.NoError(t, err)
+		assert.NoError(t, errs[0])
+		assert.Error(t, errs[1])
+		assert.NoError(t, errs[2])
+		farms = listFarms(t, repos.farm)
+		assert.ElementsMatch(t, []string{"Farm 1b", "Farm 2"}, []string{farms[0].Name, farms[1].Name})
+	})
+}
+
+func TestAuditedBy(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		// Mutations are only audited through AuditedBy
+		createFarms(t, repos.farm, "Farm 1")
+		audits, err := repos.audit.GetByEntity(model.AuditEntityFarm, 1)
+		assert.NoError(t, err)
+		assert.Empty(t, audits)
+
+		// Every mutation stores the farm before and after it
+		farmRepo := repos.farm.AuditedBy("actor")
+		farm := &model.Farm{Name: "Farm 2"}
+		require.NoError(t, farmRepo.Create(farm))
+		require.NoError(t, farmRepo.Update(farm.ID, &model.Farm{Name: "Farm 2b", Version: 1}))
+		require.NoError(t, farmRepo.Delete(&model.Farm{ID: farm.ID, Version: 2}))
+		require.NoError(t, farmRepo.Restore(&model.Farm{ID: farm.ID, Version: 3}))
+		audits, err = repos.audit.GetByEntity(model.AuditEntityFarm, farm.ID)
+		assert.NoError(t, err)
+		require.Len(t, audits, 4)
+		for i, operation := range []string{model.AuditCreate, model.AuditUpdate, model.AuditDelete, model.AuditRestore} {
+			assert.Equal(t, operation, audits[i].Operation)
+			assert.Equal(t, "actor", audits[i].Actor)
+		}
+		assert.Empty(t, audits[0].Before)
+		var before, after model.Farm
+		assert.NoError(t, json.Unmarshal(audits[1].Before, &before))
+		assert.NoError(t, json.Unmarshal(audits[1].After, &after))
+		assert.Equal(t, "Farm 2", before.Name)
+		assert.Equal(t, "Farm 2b", after.Name)
+		after = model.Farm{}
+		assert.NoError(t, json.Unmarshal(audits[2].After, &after))
+		assert.NotNil(t, after.DeletedAt)
+
+		// Deleting a farm audits the deletion of its ponds
+		pond := &model.Pond{Name: "Pond 1", FarmID: farm.ID}
+		require.NoError(t, repos.pond.AuditedBy("actor").Create(pond))
+		require.NoError(t, farmRepo.DeleteWithPonds(&model.Farm{ID: farm.ID, Version: 4}))
+		audits, err = repos.audit.GetByEntity(model.AuditEntityPond, pond.ID)
+		assert.NoError(t, err)
+		require.Len(t, audits, 2)
+		assert.Equal(t, model.AuditDelete, audits[1].Operation)
+
+		// A failing audit rolls back its mutation
+		repos.failAudits(t)
+		assert.Error(t, farmRepo.Create(&model.Farm{Name: "Farm 3"}))
+		_, err = repos.farm.GetByName("Farm 3")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Error(t, farmRepo.Update(1, &model.Farm{Name: "Farm 1b", Version: 1}))
+		stored, err := repos.farm.GetById(1)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Farm{ID: 1, Name: "Farm 1", Version: 1, OrganizationID: model.DefaultOrganizationID}, stored)
+		items := []model.FarmBulkItem{{Operation: model.BulkCreate, Farm: model.Farm{Name: "Farm 4"}}}
+		_, err = farmRepo.Bulk(items, true)
+		assert.Error(t, err)
+		_, err = repos.farm.GetByName("Farm 4")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestPondRepository(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		createFarms(t, repos.farm, "Farm 1", "Farm 2")
+
+		// Ponds must reference a farm and have unique names
+		ponds := []*model.Pond{
+			{Name: "Pond 1", FarmID: 2},
+			{Name: "Pond 2", FarmID: 1},
+			{Name: "Pond 3", FarmID: 2},
+		}
+		for _, pond := range ponds {
+			require.NoError(t, repos.pond.Create(pond))
+		}
+		assert.Equal(t, 3, ponds[2].ID)
+		assert.Error(t, repos.pond.Create(&model.Pond{Name: "Pond 4", FarmID: 42}))
+		assert.Error(t, repos.pond.Create(&model.Pond{Name: "Pond 1", FarmID: 1}))
+
+		// Update checks the version and the farm
+		pond := &model.Pond{Name: "Pond 3b", FarmID: 1, Version: 1}
+		assert.NoError(t, repos.pond.Update(3, pond))
+		assert.Equal(t, 2, pond.Version)
+		assert.ErrorIs(t, repos.pond.Update(3, &model.Pond{Name: "Pond 3c", FarmID: 1, Version: 1}), gormRepository.ErrVersionConflict)
+		assert.Error(t, repos.pond.Update(3, &model.Pond{Name: "Pond 3c", FarmID: 42, Version: 2}))
+		stored, err := repos.pond.GetById(3)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Pond{ID: 3, Name: "Pond 3b", FarmID: 1, Version: 2, OrganizationID: model.DefaultOrganizationID}, stored)
+
+		// Lookups by farm skip deleted ponds and are ordered by id
+		assert.NoError(t, repos.pond.Delete(ponds[1]))
+		byFarm, err := repos.pond.GetByFarmId(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Pond{{ID: 3, Name: "Pond 3b", FarmID: 1, Version: 2, OrganizationID: model.DefaultOrganizationID}}, byFarm)
+		byFarms, err := repos.pond.GetByFarmIds([]int{2, 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 3}, []int{byFarms[0].ID, byFarms[1].ID})
+		byIds, err := repos.pond.GetByIds([]int{3, 2, 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 3}, []int{byIds[0].ID, byIds[1].ID})
+		_, err = repos.pond.GetById(2)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		// A farm is not deleted while a pond not deleted references it
+		farm, err := repos.farm.GetById(1)
+		require.NoError(t, err)
+		assert.ErrorIs(t, repos.farm.Delete(farm), gormRepository.ErrFarmHasPonds)
+		_, err = repos.farm.GetById(1)
+		assert.NoError(t, err)
+
+		// List filters by farm
+		page, total, err := repos.pond.List(model.ListQuery{Page: 1, PageSize: 10, FarmID: 2, NameContains: "POND"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, []model.Pond{{ID: 1, Name: "Pond 1", FarmID: 2, Version: 1, OrganizationID: model.DefaultOrganizationID}}, page)
+
+		// Export joins the farm name of every pond
+		exported := make([]model.PondExport, 0)
+		assert.NoError(t, repos.pond.Export(func(pond model.PondExport) error {
+			exported = append(exported, pond)
+			return nil
+		}))
+		assert.Equal(t, []model.PondExport{
+			{Pond: model.Pond{ID: 1, Name: "Pond 1", FarmID: 2, Version: 1, OrganizationID: model.DefaultOrganizationID}, FarmName: "Farm 2"},
+			{Pond: model.Pond{ID: 3, Name: "Pond 3b", FarmID: 1, Version: 2, OrganizationID: model.DefaultOrganizationID}, FarmName: "Farm 1"},
+		}, exported)
+
+		// A best-effort batch keeps the items that succeeded
+		items := []model.PondBulkItem{
+			{Operation: model.BulkCreate, Pond: model.Pond{Name: "Pond 4", FarmID: 42}},
+			{Operation: model.BulkCreate, Pond: model.Pond{Name: "Pond 5", FarmID: 1}},
+		}
+		errs, err := repos.pond.Bulk(items, false)
+		assert.NoError(t, err)
+		assert.Error(t, errs[0])
+		assert.NoError(t, errs[1])
+		_, err = repos.pond.GetByName("Pond 4")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestOrganizationRepository(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		// Create an organization next to the default one
+		assert.NoError(t, repos.organization.Create(&model.Organization{Name: "Acme", CreatedAt: time.Now().UTC()}))
+		assert.Error(t, repos.organization.Create(&model.Organization{Name: "Acme", CreatedAt: time.Now().UTC()}))
+		acme, err := repos.organization.GetByName("Acme")
+		require.NoError(t, err)
+		organizations, _ := repos.organization.List()
+		require.Len(t, organizations, 2)
+		assert.Equal(t, "default", organizations[0].Name)
+		assert.Equal(t, acme.ID, organizations[1].ID)
+		_, err = repos.organization.GetByName("Unknown")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		acmeFarms := repos.farm.InOrganization(acme.ID)
+		acmePonds := repos.pond.InOrganization(acme.ID)
+
+		// Names are unique within an organization only, ids across them
+		defaultFarms := createFarms(t, repos.farm, "Farm 1", "Farm 2")
+		acmeFarm := createFarms(t, acmeFarms, "Farm 1")[0]
+		assert.Equal(t, 3, acmeFarm.ID)
+		assert.Equal(t, acme.ID, acmeFarm.OrganizationID)
+		assert.Error(t, acmeFarms.Create(&model.Farm{Name: "Farm 1"}))
+		assert.ErrorIs(t, acmeFarms.Create(&model.Farm{ID: 1, Name: "Farm 9"}), gormRepository.ErrIdTaken)
+		defaultPond := &model.Pond{Name: "Pond 1", FarmID: 1}
+		require.NoError(t, repos.pond.Create(defaultPond))
+		acmePond := &model.Pond{Name: "Pond 1", FarmID: acmeFarm.ID}
+		require.NoError(t, acmePonds.Create(acmePond))
+		assert.Equal(t, acme.ID, acmePond.OrganizationID)
+
+		// Farms of another organization are out of reach by id and name
+		_, err = acmeFarms.GetById(1)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = acmeFarms.GetByName("Farm 2")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		byIds, _ := acmeFarms.GetByIds([]int{1, 2, 3})
+		require.Len(t, byIds, 1)
+		assert.Equal(t, acmeFarm.ID, byIds[0].ID)
+		farms, total, _ := acmeFarms.List(model.ListQuery{Page: 1, PageSize: 10, IncludeDeleted: true})
+		assert.Equal(t, int64(1), total)
+		require.Len(t, farms, 1)
+		assert.Equal(t, acmeFarm.ID, farms[0].ID)
+		assert.ErrorIs(t, acmeFarms.Update(1, &model.Farm{Name: "Farm 1b", Version: 1}), gormRepository.ErrVersionConflict)
+		assert.ErrorIs(t, acmeFarms.Delete(&model.Farm{ID: 2, Version: 1}), gormRepository.ErrVersionConflict)
+		assert.ErrorIs(t, acmeFarms.DeleteWithPonds(&model.Farm{ID: 1, Version: 1}), gormRepository.ErrVersionConflict)
+		stored, err := repos.farm.GetById(2)
+		require.NoError(t, err)
+		assert.Equal(t, "Farm 2", stored.Name)
+		_, err = repos.pond.GetById(defaultPond.ID)
+		assert.NoError(t, err)
+
+		// Ponds of another organization are out of reach, and ponds cannot
+		// reference farms of another organization
+		_, err = acmePonds.GetById(defaultPond.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		byFarm, _ := acmePonds.GetByFarmIds([]int{1, acmeFarm.ID})
+		require.Len(t, byFarm, 1)
+		assert.Equal(t, acmePond.ID, byFarm[0].ID)
+		assert.Error(t, acmePonds.Create(&model.Pond{Name: "Pond 2", FarmID: defaultFarms[0].ID}))
+		acmePond.FarmID = defaultFarms[0].ID
+		assert.Error(t, acmePonds.Update(acmePond.ID, acmePond))
+		exported := make([]string, 0)
+		assert.NoError(t, acmePonds.Export(func(pond model.PondExport) error {
+			exported = append(exported, pond.FarmName+"/"+pond.Name)
+			return nil
+		}))
+		assert.Equal(t, []string{"Farm 1/Pond 1"}, exported)
+	})
+}
+
+func TestFarmMemberRepository(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		createFarms(t, repos.farm, "Farm 1", "Farm 2", "Farm 3")
+		for _, pond := range []*model.Pond{{Name: "Pond 1", FarmID: 1}, {Name: "Pond 2", FarmID: 2}} {
+			require.NoError(t, repos.pond.Create(pond))
+		}
+
+		// Grant members, once per farm, of existing farms only
+		grant := func(farmId int, subject string) error {
+			return repos.farmMember.Grant(&model.FarmMember{FarmID: farmId, Subject: subject, CreatedAt: time.Now().UTC()})
+		}
+		require.NoError(t, grant(1, "user-1"))
+		require.NoError(t, grant(1, "user-0"))
+		require.NoError(t, grant(3, "user-1"))
+		assert.Error(t, grant(1, "user-1"))
+		assert.Error(t, grant(42, "user-1"))
+		members, err := repos.farmMember.GetByFarmId(1)
+		require.NoError(t, err)
+		require.Len(t, members, 2)
+		assert.Equal(t, "user-0", members[0].Subject)
+		assert.Equal(t, "user-1", members[1].Subject)
+		member, err := repos.farmMember.Get(3, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, member.FarmID)
+		_, err = repos.farmMember.Get(2, "user-1")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		// A member only reaches its farms and their ponds
+		memberFarms := repos.farm.ForMember("user-1")
+		memberPonds := repos.pond.ForMember("user-1")
+		farms, total, _ := memberFarms.List(model.ListQuery{Page: 1, PageSize: 10})
+		assert.Equal(t, int64(2), total)
+		require.Len(t, farms, 2)
+		assert.Equal(t, 1, farms[0].ID)
+		assert.Equal(t, 3, farms[1].ID)
+		_, err = memberFarms.GetById(2)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.ErrorIs(t, memberFarms.Update(2, &model.Farm{Name: "Farm 2b", Version: 1}), gormRepository.ErrVersionConflict)
+		ponds := listPonds(t, memberPonds)
+		require.Len(t, ponds, 1)
+		assert.Equal(t, "Pond 1", ponds[0].Name)
+		_, err = memberPonds.GetById(2)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		exported := make([]string, 0)
+		assert.NoError(t, memberPonds.Export(func(pond model.PondExport) error {
+			exported = append(exported, pond.FarmName+"/"+pond.Name)
+			return nil
+		}))
+		assert.Equal(t, []string{"Farm 1/Pond 1"}, exported)
+
+		// Names of farms out of reach stay reserved, and created farms are
+		// granted to the member
+		assert.Error(t, memberFarms.Create(&model.Farm{Name: "Farm 2"}))
+		assert.ErrorIs(t, memberFarms.Create(&model.Farm{ID: 2, Name: "Farm 9"}), gormRepository.ErrIdTaken)
+		assert.ErrorIs(t, memberPonds.Create(&model.Pond{ID: 2, Name: "Pond 9", FarmID: 1}), gormRepository.ErrIdTaken)
+		farm4 := createFarms(t, memberFarms, "Farm 4")[0]
+		_, err = memberFarms.GetById(farm4.ID)
+		assert.NoError(t, err)
+		_, err = repos.farm.ForMember("user-0").GetById(farm4.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		// Revoking a member takes the farm out of its reach
+		require.NoError(t, repos.farmMember.Revoke(1, "user-1"))
+		assert.ErrorIs(t, repos.farmMember.Revoke(1, "user-1"), gorm.ErrRecordNotFound)
+		_, err = memberFarms.GetById(1)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		ponds = listPonds(t, memberPonds)
+		assert.Empty(t, ponds)
+	})
+}
+
+func TestLogRepository(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		// Create logs for testing
+		start := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+		logs := []model.Log{
+			{Endpoint: "GET /api/farm/", UserAgent: "Agent A", StatusCode: 200, LatencyMicros: 100},
+			{Endpoint: "GET /api/farm/", UserAgent: "Agent A", StatusCode: 200, LatencyMicros: 400},
+			{Endpoint: "GET /api/farm/", UserAgent: "Agent B", Subject: "user-1", StatusCode: 404, LatencyMicros: 300},
+			{Endpoint: "GET /api/farm/", UserAgent: "Agent A", StatusCode: 500, LatencyMicros: 200},
+			{Endpoint: "GET /api/pond/", UserAgent: "Agent A", StatusCode: 200, LatencyMicros: 50},
+		}
+		for i := range logs {
+			logs[i].CreatedAt = start.Add(time.Duration(i) * 30 * time.Minute)
+			logs[i].Method = "GET"
+			logs[i].Route = logs[i].Endpoint[len("GET "):]
+			logs[i].ClientIP = "10.0.0.1"
+			require.NoError(t, repos.log.Create(&logs[i]))
+			assert.Equal(t, i+1, logs[i].ID)
+		}
+
+		// Create a log in another organization, which none of the checks
+		// below reach
+		otherLogs := repos.log.InOrganization(2)
+		require.NoError(t, otherLogs.Create(&model.Log{CreatedAt: start.Add(time.Hour), Method: "GET", Route: "/api/farm/", Endpoint: "GET /api/farm/", UserAgent: "Agent C", StatusCode: 200, LatencyMicros: 900}))
+		otherStatistics, err := otherLogs.GetStatistics()
+		assert.NoError(t, err)
+		require.Len(t, otherStatistics, 1)
+		assert.Equal(t, int64(1), otherStatistics["GET /api/farm/"].Count)
+
+		// Check the statistics, with nearest-rank percentiles
+		expectedStatistics := &model.EndpointStatistics{
+			Count:           4,
+			UniqueUserAgent: 2,
+			LatencyMin:      100,
+			LatencyMax:      400,
+			LatencyP50:      200,
+			LatencyP90:      400,
+			LatencyP99:      400,
+			Status2xx:       2,
+			Status4xx:       1,
+			Status5xx:       1,
+			ErrorRate:       0.5,
+		}
+		statistics, err := repos.log.GetStatistics()
+		assert.NoError(t, err)
+		assert.Len(t, statistics, 2)
+		assert.Equal(t, expectedStatistics, statistics["GET /api/farm/"])
+		assert.Equal(t, int64(1), statistics["GET /api/pond/"].Count)
+
+		// Check the hourly buckets within a range
+		buckets, err := repos.log.GetBucketStatistics(start.Add(30*time.Minute), start.Add(2*time.Hour), model.BucketHour)
+		assert.NoError(t, err)
+		require.Len(t, buckets, 2)
+		assert.True(t, start.Equal(buckets[0].Bucket))
+		assert.Equal(t, int64(1), buckets[0].Endpoints["GET /api/farm/"].Count)
+		assert.True(t, start.Add(time.Hour).Equal(buckets[1].Bucket))
+		assert.Equal(t, int64(2), buckets[1].Endpoints["GET /api/farm/"].Count)
+		_, err = repos.log.GetBucketStatistics(time.Time{}, time.Time{}, "week")
+		assert.Error(t, err)
+
+		// Check the export range, ordered by id
+		exported := make([]int, 0)
+		subjects := make([]string, 0)
+		assert.NoError(t, repos.log.Export(start.Add(time.Hour), start.Add(2*time.Hour), func(log model.Log) error {
+			exported = append(exported, log.ID)
+			subjects = append(subjects, log.Subject)
+			return nil
+		}))
+		assert.Equal(t, []int{3, 4}, exported)
+		assert.Equal(t, []string{"user-1", ""}, subjects)
+	})
+}
+
+func TestApiKeyRepository(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repos repositories) {
+		// Create keys for testing
+		createdAt := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+		for i, name := range []string{"Reader", "Writer"} {
+			apiKey := &model.ApiKey{Name: name, Prefix: "delos_0000000" + name[:1], KeyHash: name + " hash", Scopes: "farm:read", Role: model.RoleViewer, OrganizationID: model.DefaultOrganizationID, CreatedAt: createdAt}
+			require.NoError(t, repos.apiKey.Create(apiKey))
+			assert.Equal(t, i+1, apiKey.ID)
+		}
+		assert.Error(t, repos.apiKey.Create(&model.ApiKey{Name: "Copy", KeyHash: "Reader hash", Scopes: "farm:read", OrganizationID: model.DefaultOrganizationID, CreatedAt: createdAt}))
+
+		// Look up active keys by hash
+		apiKey, err := repos.apiKey.GetActiveByHash("Reader hash")
+		assert.NoError(t, err)
+		assert.Equal(t, "Reader", apiKey.Name)
+		assert.Equal(t, "farm:read", apiKey.Scopes)
+		assert.Equal(t, model.RoleViewer, apiKey.Role)
+		assert.Equal(t, model.DefaultOrganizationID, apiKey.OrganizationID)
+		_, err = repos.apiKey.GetActiveByHash("Unknown hash")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		// Revoked keys are listed but no longer active
+		assert.NoError(t, repos.apiKey.Revoke(1))
+		assert.ErrorIs(t, repos.apiKey.Revoke(1), gorm.ErrRecordNotFound)
+		assert.ErrorIs(t, repos.apiKey.Revoke(42), gorm.ErrRecordNotFound)
+		_, err = repos.apiKey.GetActiveByHash("Reader hash")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		apiKeys, err := repos.apiKey.List()
+		assert.NoError(t, err)
+		require.Len(t, apiKeys, 2)
+		assert.NotNil(t, apiKeys[0].RevokedAt)
+		assert.Nil(t, apiKeys[1].RevokedAt)
+	})
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/database"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	gormRepository "github.com/WillyWilsen/Delos-Task-Assignment.git/repository"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/utility"
+)
+
+const (
+	benchmarkLogCount      = 100000
+	benchmarkEndpointCount = 20
+)
+
+// baselineEndpointStatisticsQuery is the query of the former implementation
+// of the statistics, aggregating the logs of a single endpoint. It is a frozen
+// baseline to measure LogRepository.GetStatistics against, and is not meant to
+// follow changes of the repository.
+const baselineEndpointStatisticsQuery = `
+	SELECT
+		COUNT(*) AS count,
+		COUNT(DISTINCT user_agent) AS unique_user_agent,
+		COALESCE(MIN(latency_us), 0) AS latency_min_us,
+		COALESCE(MAX(latency_us), 0) AS latency_max_us,
+		COALESCE(MIN(CASE WHEN latency_rank >= 0.50 * latency_total THEN latency_us END), 0) AS latency_p50_us,
+		COALESCE(MIN(CASE WHEN latency_rank >= 0.90 * latency_total THEN latency_us END), 0) AS latency_p90_us,
+		COALESCE(MIN(CASE WHEN latency_rank >= 0.99 * latency_total THEN latency_us END), 0) AS latency_p99_us,
+		COUNT(CASE WHEN status_code >= 200 AND status_code < 300 THEN 1 END) AS status_2xx,
+		COUNT(CASE WHEN status_code >= 400 AND status_code < 500 THEN 1 END) AS status_4xx,
+		COUNT(CASE WHEN status_code >= 500 THEN 1 END) AS status_5xx
+	FROM (
+		SELECT
+			user_agent,
+			latency_us,
+			status_code,
+			ROW_NUMBER() OVER (ORDER BY latency_us) AS latency_rank,
+			COUNT(*) OVER () AS latency_total
+		FROM logs
+		WHERE organization_id = ? AND endpoint = ?
+	) ranked_logs`
+
+// baselineStatisticsPerEndpoint is the former implementation of the
+// statistics, kept as a baseline: it lists the endpoints, then aggregates each
+// one in its own query
+func baselineStatisticsPerEndpoint(db *gorm.DB, organizationId int) (model.Statistics, error) {
+	var endpoints []string
+	if err := db.Table("logs").Where("organization_id = ?", organizationId).Distinct("endpoint").Pluck("endpoint", &endpoints).Error; err != nil {
+		return nil, err
+	}
+
+	statistics := make(model.Statistics)
+	for _, endpoint := range endpoints {
+		var endpointStatistics model.EndpointStatistics
+		if err := db.Raw(baselineEndpointStatisticsQuery, organizationId, endpoint).Scan(&endpointStatistics).Error; err != nil {
+			return nil, err
+		}
+		endpointStatistics.SetErrorRate()
+		statistics[endpoint] = &endpointStatistics
+	}
+	return statistics, nil
+}
+
+// seedBenchmarkLogs opens an in-memory SQLite database with every migration
+// applied, holding benchmarkLogCount logs spread over benchmarkEndpointCount
+// endpoints
+func seedBenchmarkLogs(b *testing.B) *gorm.DB {
+	var configuration utility.Configuration
+	configuration.Database.Driver = database.DriverSQLite
+	db := openTestDatabase(b, configuration).Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+
+	start := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	organizationId := model.DefaultOrganizationID
+	logs := make([]model.Log, 0, benchmarkLogCount)
+	for i := 0; i < benchmarkLogCount; i++ {
+		logs = append(logs, model.Log{
+			CreatedAt:      start.Add(time.Duration(i) * time.Second),
+			Method:         "GET",
+			Route:          fmt.Sprintf("/api/endpoint/%d", i%benchmarkEndpointCount),
+			Endpoint:       fmt.Sprintf("GET /api/endpoint/%d", i%benchmarkEndpointCount),
+			UserAgent:      fmt.Sprintf("Agent %d", i%50),
+			StatusCode:     200,
+			LatencyMicros:  int64(i % 1000),
+			OrganizationID: &organizationId,
+		})
+	}
+	if err := db.Table("logs").CreateInBatches(logs, 500).Error; err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+func BenchmarkGetStatistics(b *testing.B) {
+	logRepo := gormRepository.NewLogRepository(seedBenchmarkLogs(b)).InOrganization(model.DefaultOrganizationID)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := logRepo.GetStatistics(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetStatistics_PerEndpointBaseline(b *testing.B) {
+	db := seedBenchmarkLogs(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := baselineStatisticsPerEndpoint(db, model.DefaultOrganizationID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/handler"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/model"
+	"github.com/WillyWilsen/Delos-Task-Assignment.git/test/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func seedStatisticsLogs(logRepo *repository.MockLogRepository) {
+	start := time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC)
+	logs := []model.Log{
+		{CreatedAt: start, Endpoint: "GET /api/farm/", UserAgent: "Agent 1"},
+		{CreatedAt: start.Add(10 * time.Minute), Endpoint: "GET /api/farm/", UserAgent: "Agent 2"},
+		{CreatedAt: start.Add(20 * time.Minute), Endpoint: "POST /api/farm/", UserAgent: "Agent 1"},
+		{CreatedAt: start.Add(70 * time.Minute), Endpoint: "GET /api/farm/", UserAgent: "Agent 1"},
+		{CreatedAt: start.Add(25 * time.Hour), Endpoint: "GET /api/farm/", UserAgent: "Agent 1"},
+	}
+	for i := range logs {
+		logRepo.Create(&logs[i])
+	}
+}
+
+func TestGetStatistics(t *testing.T) {
+	// Create mock repositories
+	logRepo := repository.NewMockLogRepository()
+	seedStatisticsLogs(logRepo)
+
+	// Create handler with mock repositories
+	statisticsHandler := handler.NewStatisticsHandler(logRepo)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/statistics", statisticsHandler.GetStatistics)
+
+	// Create a test request
+	request, _ := http.NewRequest("GET", "/statistics", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Data model.Statistics `json:"data"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.Statistics{
+		"GET /api/farm/":  {Count: 4, UniqueUserAgent: 2},
+		"POST /api/farm/": {Count: 1, UniqueUserAgent: 1},
+	}, actualResponse.Data)
+}
+
+func TestGetStatistics_Bucket(t *testing.T) {
+	// Create mock repositories
+	logRepo := repository.NewMockLogRepository()
+	seedStatisticsLogs(logRepo)
+
+	// Create handler with mock repositories
+	statisticsHandler := handler.NewStatisticsHandler(logRepo)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/statistics", statisticsHandler.GetStatistics)
+
+	// Create a test request
+	request, _ := http.NewRequest("GET", "/statistics?from=2023-07-01T00:00:00Z&to=2023-07-02T00:00:00Z&bucket=hour", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Data []model.BucketStatistics `json:"data"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Len(t, actualResponse.Data, 2)
+	assert.True(t, time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC).Equal(actualResponse.Data[0].Bucket))
+	assert.Equal(t, model.Statistics{
+		"GET /api/farm/":  {Count: 2, UniqueUserAgent: 2},
+		"POST /api/farm/": {Count: 1, UniqueUserAgent: 1},
+	}, actualResponse.Data[0].Endpoints)
+	assert.True(t, time.Date(2023, 7, 1, 11, 0, 0, 0, time.UTC).Equal(actualResponse.Data[1].Bucket))
+	assert.Equal(t, model.Statistics{
+		"GET /api/farm/": {Count: 1, UniqueUserAgent: 1},
+	}, actualResponse.Data[1].Endpoints)
+}
+
+func TestGetStatistics_InvalidQuery(t *testing.T) {
+	// Create mock repositories
+	logRepo := repository.NewMockLogRepository()
+
+	// Create handler with mock repositories
+	statisticsHandler := handler.NewStatisticsHandler(logRepo)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/statistics", statisticsHandler.GetStatistics)
+
+	for _, query := range []string{"bucket=week", "from=yesterday", "from=2023-07-02T00:00:00Z&to=2023-07-01T00:00:00Z"} {
+		// Create a test request with an invalid query
+		request, _ := http.NewRequest("GET", "/statistics?"+query, nil)
+		request.Header.Set("User-Agent", "Test Agent")
+		responseRecorder := httptest.NewRecorder()
+
+		// Perform the request
+		router.ServeHTTP(responseRecorder, request)
+
+		// Check the response status code
+		assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+
+		// Check the response body
+		expectedResponse := gin.H{
+			"code":    float64(http.StatusBadRequest),
+			"status":  "error",
+			"message": "Invalid request query",
+		}
+		actualResponse := gin.H{}
+		err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedResponse, actualResponse)
+	}
+}
+
+func TestGetStatistics_LatencyAndStatus(t *testing.T) {
+	// Create mock repositories
+	logRepo := repository.NewMockLogRepository()
+	for i := 1; i <= 10; i++ {
+		statusCode := http.StatusOK
+		if i == 9 {
+			statusCode = http.StatusNotFound
+		} else if i == 10 {
+			statusCode = http.StatusInternalServerError
+		}
+		logRepo.Create(&model.Log{
+			CreatedAt:     time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC),
+			Endpoint:      "GET /api/pond/:id",
+			UserAgent:     "Agent 1",
+			StatusCode:    statusCode,
+			LatencyMicros: int64(11-i) * 100,
+		})
+	}
+
+	// Create handler with mock repositories
+	statisticsHandler := handler.NewStatisticsHandler(logRepo)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/statistics", statisticsHandler.GetStatistics)
+
+	// Create a test request
+	request, _ := http.NewRequest("GET", "/statistics", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+	// Check the response body
+	var actualResponse struct {
+		Data model.Statistics `json:"data"`
+	}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.Statistics{
+		"GET /api/pond/:id": {
+			Count:           10,
+			UniqueUserAgent: 1,
+			LatencyMin:      100,
+			LatencyMax:      1000,
+			LatencyP50:      500,
+			LatencyP90:      900,
+			LatencyP99:      1000,
+			Status2xx:       8,
+			Status4xx:       1,
+			Status5xx:       1,
+			ErrorRate:       0.2,
+		},
+	}, actualResponse.Data)
+}
+
+func TestGetStatistics_Failed(t *testing.T) {
+	// Create mock repositories
+	logRepo := repository.NewMockLogRepository()
+	logRepo.SetStatisticsError(errors.New("Database unavailable"))
+
+	// Create handler with mock repositories
+	statisticsHandler := handler.NewStatisticsHandler(logRepo)
+
+	// Create a Gin router and set up the handler route
+	router := gin.Default()
+	router.Use(withRole(model.RoleAdmin))
+	router.GET("/statistics", statisticsHandler.GetStatistics)
+
+	// Create a test request
+	request, _ := http.NewRequest("GET", "/statistics", nil)
+	request.Header.Set("User-Agent", "Test Agent")
+	responseRecorder := httptest.NewRecorder()
+
+	// Perform the request
+	router.ServeHTTP(responseRecorder, request)
+
+	// Check the response status code
+	assert.Equal(t, http.StatusInternalServerError, responseRecorder.Code)
+
+	// Check the response body
+	expectedResponse := gin.H{
+		"code":    float64(http.StatusInternalServerError),
+		"status":  "error",
+		"message": "Failed to fetch statistic",
+	}
+	actualResponse := gin.H{}
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &actualResponse)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResponse, actualResponse)
+}
@@ -0,0 +1,27 @@
+package utility
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ApiKeyPrefixLength is the length of the key prefix kept in clear to tell
+// keys apart
+const ApiKeyPrefixLength = 14
+
+// GenerateApiKey returns a random "delos_" key with 256 bits of entropy
+func GenerateApiKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "delos_" + hex.EncodeToString(secret), nil
+}
+
+// HashApiKey hashes a key for storage. The keys are random, so a fast hash is
+// enough where a password would need a slow one.
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,192 @@
+package utility
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtLeeway absorbs the clock skew with the issuing services
+const jwtLeeway = 30 * time.Second
+
+// jwtMinSecretLength is the HS256 key size recommended by RFC 7518
+const jwtMinSecretLength = 32
+
+// JwtVerifier validates bearer tokens against the issuers of the
+// configuration. A verifier without issuers rejects every token.
+type JwtVerifier struct {
+	issuers map[string]*jwtIssuer
+}
+
+type jwtIssuer struct {
+	audience  string
+	secret    []byte
+	publicKey *rsa.PublicKey
+	jwks      map[string]*rsa.PublicKey
+}
+
+// NewJwtVerifier loads the keys of every configured issuer
+func NewJwtVerifier(config Configuration) (*JwtVerifier, error) {
+	verifier := &JwtVerifier{issuers: make(map[string]*jwtIssuer)}
+	for _, issuerConfig := range config.Auth.JwtIssuers {
+		if issuerConfig.Issuer == "" {
+			return nil, errors.New("jwt issuer is missing its issuer")
+		}
+		if verifier.issuers[issuerConfig.Issuer] != nil {
+			return nil, fmt.Errorf("jwt issuer %q is configured twice", issuerConfig.Issuer)
+		}
+		if issuerConfig.HS256Secret == "" && issuerConfig.RS256PublicKeyFile == "" && issuerConfig.JwksFile == "" {
+			return nil, fmt.Errorf("jwt issuer %q has no key", issuerConfig.Issuer)
+		}
+
+		issuer := &jwtIssuer{audience: issuerConfig.Audience}
+		if issuerConfig.HS256Secret != "" {
+			if len(issuerConfig.HS256Secret) < jwtMinSecretLength {
+				return nil, fmt.Errorf("jwt issuer %q hs256_secret must be at least %d bytes", issuerConfig.Issuer, jwtMinSecretLength)
+			}
+			issuer.secret = []byte(issuerConfig.HS256Secret)
+		}
+		if issuerConfig.RS256PublicKeyFile != "" {
+			pem, err := os.ReadFile(appFile(config, issuerConfig.RS256PublicKeyFile))
+			if err != nil {
+				return nil, fmt.Errorf("jwt issuer %q: %w", issuerConfig.Issuer, err)
+			}
+			if issuer.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+				return nil, fmt.Errorf("jwt issuer %q: %w", issuerConfig.Issuer, err)
+			}
+		}
+		if issuerConfig.JwksFile != "" {
+			jwks, err := loadJwks(appFile(config, issuerConfig.JwksFile))
+			if err != nil {
+				return nil, fmt.Errorf("jwt issuer %q: %w", issuerConfig.Issuer, err)
+			}
+			issuer.jwks = jwks
+		}
+		verifier.issuers[issuerConfig.Issuer] = issuer
+	}
+	return verifier, nil
+}
+
+// Verify checks the signature, expiry, issuer and audience of a token and
+// returns its claims, which always hold a subject
+func (v *JwtVerifier) Verify(token string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(
+		token,
+		claims,
+		v.key,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtLeeway),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Audience
+	issuerName, _ := claims.GetIssuer()
+	if audience := v.issuers[issuerName].audience; audience != "" {
+		audiences, _ := claims.GetAudience()
+		found := false
+		for _, tokenAudience := range audiences {
+			found = found || tokenAudience == audience
+		}
+		if !found {
+			return nil, fmt.Errorf("token is not intended for %q", audience)
+		}
+	}
+
+	// Subject
+	if subject, _ := claims.GetSubject(); subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return claims, nil
+}
+
+// key picks the verification key from the issuer of the still unverified
+// token, so a key is only ever used with the algorithm it was configured for
+func (v *JwtVerifier) key(token *jwt.Token) (interface{}, error) {
+	issuerName, _ := token.Claims.GetIssuer()
+	issuer := v.issuers[issuerName]
+	if issuer == nil {
+		return nil, fmt.Errorf("unknown issuer %q", issuerName)
+	}
+
+	switch token.Method {
+	case jwt.SigningMethodHS256:
+		if issuer.secret != nil {
+			return issuer.secret, nil
+		}
+	case jwt.SigningMethodRS256:
+		kid, _ := token.Header["kid"].(string)
+		if key := issuer.jwks[kid]; key != nil {
+			return key, nil
+		}
+		if issuer.publicKey != nil {
+			return issuer.publicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("issuer %q has no %s key", issuerName, token.Method.Alg())
+}
+
+// loadJwks reads the RS256 signing keys of a JWKS file by "kid", keys without
+// one being stored under ""
+func loadJwks(path string) (map[string]*rsa.PublicKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid jwks file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		// Not an RS256 signing key
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != jwt.SigningMethodRS256.Alg()) {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwks key %q: %w", jwk.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid jwks key %q exponent", jwk.Kid)
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks file has no RS256 signing key")
+	}
+	return keys, nil
+}
+
+// appFile resolves a configured path against the app path
+func appFile(config Configuration, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(config.AppPath, path)
+}
@@ -0,0 +1,70 @@
+package utility
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrUnsupportedSpreadsheet = errors.New("unsupported spreadsheet format, expected .csv or .xlsx")
+
+// ReadSpreadsheet passes every row of a CSV file or of the first sheet of an
+// XLSX file to each, picking the format from the file name extension, and
+// stops at the first error each returns. Blank lines are passed as empty rows
+// so row numbers match the file.
+func ReadSpreadsheet(reader io.Reader, fileName string, each func(record []string) error) error {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		csvReader := csv.NewReader(reader)
+		csvReader.FieldsPerRecord = -1
+		csvReader.TrimLeadingSpace = true
+
+		rows := 0
+		for {
+			record, err := csvReader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			line, _ := csvReader.FieldPos(0)
+			for ; rows < line-1; rows++ {
+				if err := each(nil); err != nil {
+					return err
+				}
+			}
+			rows++
+			if err := each(record); err != nil {
+				return err
+			}
+		}
+	case ".xlsx":
+		file, err := excelize.OpenReader(reader)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		rows, err := file.Rows(file.GetSheetName(0))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			record, err := rows.Columns()
+			if err != nil {
+				return err
+			}
+			if err := each(record); err != nil {
+				return err
+			}
+		}
+		return rows.Error()
+	default:
+		return ErrUnsupportedSpreadsheet
+	}
+}
@@ -13,10 +13,15 @@ import (
 )
 
 type tsHttp struct {
-	HttpPort                          string `json:"http_port"`
+	HttpPort string `json:"http_port"`
 }
 
+// tsDatabase Driver is "mysql" (default), "postgres" or "sqlite". SQLite only
+// uses Path, a file or ":memory:". SSLMode only applies to Postgres.
 type tsDatabase struct {
+	Driver       string `json:"driver"`
+	Path         string `json:"path"`
+	SSLMode      string `json:"sslmode"`
 	Hostname     string `json:"hostname"`
 	Port         int    `json:"port"`
 	Username     string `json:"username"`
@@ -24,9 +29,34 @@ type tsDatabase struct {
 	DatabaseName string `json:"database_name"`
 }
 
+// tsApi StrictPut makes PUT on a missing id respond 404 instead of creating
+// the resource at that id
+type tsApi struct {
+	StrictPut bool `json:"strict_put"`
+}
+
+// tsJwtIssuer verifies the tokens whose "iss" claim is Issuer, and their
+// "aud" claim when Audience is set. HS256 tokens are checked against
+// HS256Secret and RS256 tokens against the PEM public key at RS256PublicKeyFile
+// or the keys of the local JWKS file at JwksFile, picked by "kid". Relative
+// paths are resolved against the app path.
+type tsJwtIssuer struct {
+	Issuer             string `json:"issuer"`
+	Audience           string `json:"audience"`
+	HS256Secret        string `json:"hs256_secret"`
+	RS256PublicKeyFile string `json:"rs256_public_key_file"`
+	JwksFile           string `json:"jwks_file"`
+}
+
+type tsAuth struct {
+	JwtIssuers []tsJwtIssuer `json:"jwt_issuers"`
+}
+
 type Configuration struct {
 	Http     tsHttp     `json:"http"`
 	Database tsDatabase `json:"database"`
+	Api      tsApi      `json:"api"`
+	Auth     tsAuth     `json:"auth"`
 
 	AppPath string `json:"app_path"`
 }
@@ -109,8 +139,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "X-Requested-With, X-Auth-Token, Content-Type, Content-Length, Authorization, Access-Control-Allow-Headers, Accept, Access-Control-Allow-Methods, Access-Control-Allow-Origin, Access-Control-Allow-Credentials")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, HEAD, PUT")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "X-Requested-With, X-Auth-Token, X-API-Key, Content-Type, Content-Length, Authorization, Access-Control-Allow-Headers, Accept, Access-Control-Allow-Methods, Access-Control-Allow-Origin, Access-Control-Allow-Credentials")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, HEAD, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -119,4 +149,36 @@ func CORSMiddleware() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to a JSON document
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	var documentValue, patchValue interface{}
+	if err := json.Unmarshal(document, &documentValue); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatchValue(documentValue, patchValue))
+}
+
+func mergePatchValue(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatchValue(targetObject[key], value)
+		}
+	}
+	return targetObject
+}